@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+)
+
+// admin runs one-off maintenance commands against the configured database:
+//
+//	go run ./cmd/admin <command>
+var commands = map[string]func() error{
+	"migrate-amounts": migrateAmounts,
+	"rotate-keys":     rotateKeys,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: admin <command>")
+		fmt.Fprintln(os.Stderr, "commands:")
+		fmt.Fprintln(os.Stderr, "  migrate-amounts   convert float coin amounts to int64 base units")
+		fmt.Fprintln(os.Stderr, "  rotate-keys       re-encrypt stored private keys under AES_ACTIVE_KEY_ID")
+		os.Exit(2)
+	}
+
+	config.LoadConfig()
+	if err := db.ConnectMongo(); err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+
+	if err := commands[os.Args[1]](); err != nil {
+		log.Fatalf("%s failed: %v", os.Args[1], err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// amountFields are the value fields, at any depth, that hold coin amounts.
+var amountFields = map[string]bool{
+	"amount":         true,
+	"fee":            true,
+	"balance":        true,
+	"zakat_deducted": true,
+}
+
+// amountPaths lists, per collection, where float amounts can appear.
+var amountPaths = map[string][]string{
+	"utxos":                {"amount"},
+	"transactions":         {"amount", "fee", "outputs.amount"},
+	"pending_transactions": {"amount", "fee", "outputs.amount"},
+	"blocks":               {"transactions.amount", "transactions.fee", "transactions.outputs.amount"},
+	"wallets":              {"balance"},
+	"users":                {"zakat_deducted"},
+}
+
+// migrateAmounts rewrites float64 coin amounts as int64 base units.
+// Only documents still holding doubles are touched, so it can be re-run.
+// Canonical tx ids and signatures are unaffected: both encode amounts as
+// 8-decimal strings, which the conversion preserves.
+func migrateAmounts() error {
+	ctx := context.Background()
+	for col, paths := range amountPaths {
+		n, err := migrateCollection(ctx, col, paths)
+		if err != nil {
+			return err
+		}
+		log.Printf("%s: %d documents converted", col, n)
+	}
+	return nil
+}
+
+func migrateCollection(ctx context.Context, col string, paths []string) (int, error) {
+	var or []bson.M
+	for _, p := range paths {
+		or = append(or, bson.M{p: bson.M{"$type": "double"}})
+	}
+
+	cur, err := db.Col(col).Find(ctx, bson.M{"$or": or})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	n := 0
+	for cur.Next(ctx) {
+		var doc bson.D
+		if err := cur.Decode(&doc); err != nil {
+			return n, err
+		}
+		if _, err := db.Col(col).ReplaceOne(ctx, bson.M{"_id": docID(doc)}, convertAmounts(doc)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, cur.Err()
+}
+
+func docID(doc bson.D) interface{} {
+	for _, e := range doc {
+		if e.Key == "_id" {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+// convertAmounts walks a decoded document and replaces float values of
+// amount fields with base units.
+func convertAmounts(doc bson.D) bson.D {
+	for i, e := range doc {
+		switch v := e.Value.(type) {
+		case float64:
+			if amountFields[e.Key] {
+				doc[i].Value = int64(models.AmountFromCoins(v))
+			}
+		case bson.D:
+			doc[i].Value = convertAmounts(v)
+		case bson.A:
+			for j, item := range v {
+				if sub, ok := item.(bson.D); ok {
+					v[j] = convertAmounts(sub)
+				}
+			}
+		}
+	}
+	return doc
+}
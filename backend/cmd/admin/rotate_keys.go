@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// rotateBatchSize is how many documents are re-encrypted per bulk write.
+const rotateBatchSize = 500
+
+// encryptedFields lists, per collection, the fields holding values
+// encrypted with crypto.EncryptPrivateKey.
+var encryptedFields = map[string][]string{
+	"users":     {"encrypted_priv_key", "encrypted_seed"},
+	"addresses": {"encrypted_priv_key"},
+}
+
+// rotateKeys re-encrypts every stored private key and seed under the
+// active AES key, in batches. Values already under it are skipped, so it
+// can be re-run after an interruption; once it reports nothing left the
+// old keys can be dropped from AES_KEYS.
+func rotateKeys() error {
+	active := config.AppConfig.AESActiveKey
+	if active == "" {
+		return errors.New("no active key: set AES_KEYS and AES_ACTIVE_KEY_ID")
+	}
+	ctx := context.Background()
+	for col, fields := range encryptedFields {
+		for _, field := range fields {
+			n, err := rotateField(ctx, col, field, active)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", col, field, err)
+			}
+			log.Printf("%s.%s: %d values re-encrypted with key %s", col, field, n, active)
+		}
+	}
+	return nil
+}
+
+func rotateField(ctx context.Context, col, field, active string) (int, error) {
+	prefix := primitive.Regex{Pattern: "^" + regexp.QuoteMeta("v1:"+active+":")}
+	filter := bson.M{field: bson.M{"$exists": true, "$ne": "", "$not": prefix}}
+	opts := options.Find().SetLimit(rotateBatchSize).SetProjection(bson.M{field: 1})
+
+	n := 0
+	for {
+		cur, err := db.Col(col).Find(ctx, filter, opts)
+		if err != nil {
+			return n, err
+		}
+		var docs []bson.M
+		if err := cur.All(ctx, &docs); err != nil {
+			return n, err
+		}
+		if len(docs) == 0 {
+			return n, nil
+		}
+
+		var writes []mongo.WriteModel
+		for _, doc := range docs {
+			old, _ := doc[field].(string)
+			rotated, changed, err := appCrypto.ReencryptPrivateKey(old)
+			if err != nil {
+				return n, fmt.Errorf("document %v: %w", doc["_id"], err)
+			}
+			if !changed {
+				continue
+			}
+			// only replace the value we read, in case it changed meanwhile
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": doc["_id"], field: old}).
+				SetUpdate(bson.M{"$set": bson.M{field: rotated}}))
+		}
+		if len(writes) == 0 {
+			return n, nil
+		}
+		res, err := db.Col(col).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return n, err
+		}
+		n += int(res.ModifiedCount)
+		log.Printf("%s.%s: batch of %d done, %d so far", col, field, len(writes), n)
+	}
+}
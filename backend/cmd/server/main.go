@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/api"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/middleware"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/scheduler"
 )
 
 func main() {
@@ -20,6 +25,23 @@ func main() {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
 
+	// Indexes + reconcile a block left half-applied by a crash
+	if err := db.EnsureIndexes(context.Background()); err != nil {
+		log.Println("Failed to create indexes:", err)
+	}
+	if err := blockchain.RecoverTip(context.Background()); err != nil {
+		log.Println("Chain tip recovery failed, manual check required:", err)
+	}
+
+	// Rebuild the in-process mempool from pending txs and evict stale ones hourly
+	if err := mempool.Load(context.Background()); err != nil {
+		log.Println("Failed to load mempool:", err)
+	}
+	go mempool.RunEvictor(context.Background(), time.Hour, config.AppConfig.MempoolMaxAge)
+
+	// Pay recurring transfers as they fall due
+	go scheduler.RunRecurring(context.Background(), time.Minute)
+
 	// Create Gin router
 	r := gin.Default()
 
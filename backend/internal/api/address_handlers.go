@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/hdwallet"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// userSeed decrypts the BIP-39 seed of a seed phrase wallet.
+func userSeed(user models.User) ([]byte, error) {
+	if user.EncryptedSeed == "" {
+		return nil, errors.New("wallet has no seed phrase")
+	}
+	seedHex, err := appCrypto.DecryptPrivateKey(user.EncryptedSeed)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(seedHex)
+}
+
+// normalizeLabel trims and lower-cases an address label.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// userAddresses returns user's main wallet followed by their extra
+// addresses in creation order.
+func userAddresses(ctx context.Context, user models.User) ([]models.Address, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cur, err := db.Col("addresses").Find(ctx, bson.M{"owner_wallet": user.WalletID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var extra []models.Address
+	if err := cur.All(ctx, &extra); err != nil {
+		return nil, err
+	}
+	return append([]models.Address{models.MainAddress(user)}, extra...), nil
+}
+
+// findAddress picks the address named by ref, a wallet id or a label; ""
+// names the main wallet.
+func findAddress(addrs []models.Address, ref string) (models.Address, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return addrs[0], true
+	}
+	for _, a := range addrs {
+		if a.WalletID == ref || a.Label == normalizeLabel(ref) {
+			return a, true
+		}
+	}
+	return models.Address{}, false
+}
+
+// selectAddresses loads the caller and their addresses, narrowed to the one
+// named by the address query parameter if given. On failure it writes the
+// error response and returns false.
+func selectAddresses(ctx context.Context, c *gin.Context) (models.User, []models.Address, bool) {
+	user, err := loadUser(ctx, c.GetString("wallet_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return user, nil, false
+	}
+	addrs, err := userAddresses(ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return user, nil, false
+	}
+	if ref := c.Query("address"); ref != "" {
+		a, ok := findAddress(addrs, ref)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+			return user, nil, false
+		}
+		addrs = []models.Address{a}
+	}
+	return user, addrs, true
+}
+
+// walletIDs lists the wallet ids of addrs.
+func walletIDs(addrs []models.Address) []string {
+	ids := make([]string, len(addrs))
+	for i, a := range addrs {
+		ids[i] = a.WalletID
+	}
+	return ids
+}
+
+// saveAddress stores address index of user under the current AES key,
+// replacing the key of an earlier copy, and registers it as a wallet so it
+// can be paid. label is only used for a new address.
+func saveAddress(ctx context.Context, user models.User, label string, index int, pubHex, privHex string) (models.Address, error) {
+	encrypted, err := appCrypto.EncryptPrivateKey(privHex)
+	if err != nil {
+		return models.Address{}, err
+	}
+	var addr models.Address
+	err = db.Col("addresses").FindOneAndUpdate(ctx,
+		bson.M{"wallet_id": appCrypto.WalletIDFromPublicKeyHex(pubHex)},
+		bson.M{
+			"$set": bson.M{
+				"user_id":            user.ID,
+				"owner_wallet":       user.WalletID,
+				"index":              index,
+				"public_key":         pubHex,
+				"encrypted_priv_key": encrypted,
+			},
+			"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID().Hex(),
+				"label":      label,
+				"created_at": time.Now().UTC(),
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&addr)
+	if err != nil {
+		return models.Address{}, err
+	}
+
+	_, err = db.Col("wallets").UpdateOne(ctx,
+		bson.M{"wallet_id": addr.WalletID},
+		bson.M{"$setOnInsert": models.Wallet{ID: primitive.NewObjectID().Hex(), UserID: user.ID, WalletID: addr.WalletID}},
+		options.Update().SetUpsert(true),
+	)
+	return addr, err
+}
+
+// GET /api/wallet/addresses
+// Lists the caller's addresses, the main wallet first, with their balances.
+func ListAddresses(c *gin.Context) {
+	ctx := context.Background()
+
+	_, addrs, ok := selectAddresses(ctx, c)
+	if !ok {
+		return
+	}
+	type addressView struct {
+		models.Address
+		Balances utxo.Balances `json:"balances"`
+	}
+	out := make([]addressView, 0, len(addrs))
+	for _, a := range addrs {
+		b, err := utxo.GetBalances(ctx, a.WalletID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "balance error"})
+			return
+		}
+		out = append(out, addressView{Address: a, Balances: b})
+	}
+	c.JSON(http.StatusOK, gin.H{"addresses": out})
+}
+
+type AddressRequest struct {
+	Label string `json:"label" binding:"required,max=32"`
+}
+
+// checkLabel rejects a label that is reserved or already names another of
+// addrs.
+func checkLabel(addrs []models.Address, label, walletID string) error {
+	if label == "" {
+		return errors.New("label must not be blank")
+	}
+	for _, a := range addrs {
+		if a.Label == label && a.WalletID != walletID {
+			return fmt.Errorf("label %q is already in use", label)
+		}
+	}
+	return nil
+}
+
+// POST /api/wallet/addresses
+// Adds a labelled address with its own key: the next one derived from the
+// caller's seed, or a random key for wallets without a seed phrase.
+func CreateAddress(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	label := normalizeLabel(req.Label)
+
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	addrs, err := userAddresses(ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	if err := checkLabel(addrs, label, ""); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	var index int
+	var pubHex, privHex string
+	if user.EncryptedSeed == "" {
+		pubHex, privHex, err = appCrypto.GenerateKeyPair()
+	} else {
+		// claim an index first so concurrent requests never derive the same one
+		err = db.Col("users").FindOneAndUpdate(ctx,
+			bson.M{"_id": user.ID},
+			bson.M{"$inc": bson.M{"next_address_index": 1}},
+		).Decode(&user)
+		var seed []byte
+		if err == nil {
+			index = max(user.NextAddressIndex, 1)
+			seed, err = userSeed(user)
+		}
+		if err == nil {
+			pubHex, privHex, err = appCrypto.DeriveReceiveKey(seed, index)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create address key"})
+		return
+	}
+	addr, err := saveAddress(ctx, user, label, index, pubHex, privHex)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save address"})
+		return
+	}
+
+	logger.AddSystemLog(c, "address_created", fmt.Sprintf("wallet=%s address=%s label=%s index=%d", walletID, addr.WalletID, label, index))
+	c.JSON(http.StatusCreated, addr)
+}
+
+// PUT /api/wallet/addresses/:id
+// Renames an extra address, named by wallet id or current label.
+func RenameAddress(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	label := normalizeLabel(req.Label)
+
+	user, addrs, ok := selectAddresses(ctx, c)
+	if !ok {
+		return
+	}
+	a, ok := findAddress(addrs, c.Param("id"))
+	if !ok || a.WalletID == user.WalletID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "address not found"})
+		return
+	}
+	if err := checkLabel(addrs, label, a.WalletID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, err := db.Col("addresses").UpdateOne(ctx, bson.M{"_id": a.ID}, bson.M{"$set": bson.M{"label": label}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	logger.AddSystemLog(c, "address_renamed", fmt.Sprintf("wallet=%s address=%s label=%s", walletID, a.WalletID, label))
+	a.Label = label
+	c.JSON(http.StatusOK, a)
+}
+
+type RestoreWalletRequest struct {
+	Mnemonic   string `json:"mnemonic" binding:"required"`
+	Passphrase string `json:"passphrase"`
+}
+
+// POST /api/wallet/restore
+// Rebuilds the caller's keys from their seed phrase, e.g. after the AES
+// key they were stored under was lost: the seed must derive the caller's
+// wallet id. Receive addresses are recovered with a gap-limit scan over
+// the UTXO set, and every key is stored again under the current AES key.
+func RestoreWallet(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var req RestoreWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seed, err := appCrypto.SeedFromMnemonic(req.Mnemonic, req.Passphrase)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+	pubHex, privHex, err := appCrypto.DeriveReceiveKey(seed, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to derive wallet key"})
+		return
+	}
+	if appCrypto.WalletIDFromPublicKeyHex(pubHex) != user.WalletID {
+		logger.AddSystemLog(c, "wallet_restore_failed", fmt.Sprintf("wallet=%s reason=seed_mismatch", walletID))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "seed phrase does not belong to this wallet"})
+		return
+	}
+	found, next, err := hdwallet.Scan(ctx, seed, config.AppConfig.HDGapLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+		return
+	}
+
+	encryptedPriv, err := appCrypto.EncryptPrivateKey(privHex)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encrypt private key"})
+		return
+	}
+	encryptedSeed, err := appCrypto.EncryptPrivateKey(hex.EncodeToString(seed))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encrypt seed"})
+		return
+	}
+
+	// addresses handed out before but never paid lie past the scan; keep
+	// them and re-store their keys too
+	var known []models.Address
+	cur, err := db.Col("addresses").Find(ctx, bson.M{"owner_wallet": walletID})
+	if err == nil {
+		err = cur.All(ctx, &known)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	indexes := map[int]bool{}
+	for _, f := range found[1:] {
+		indexes[f.Index] = true
+	}
+	for _, a := range known {
+		// random keys of addresses made before the seed can't be rebuilt
+		if a.Index > 0 {
+			indexes[a.Index] = true
+			next = max(next, a.Index+1)
+		}
+	}
+	for index := range indexes {
+		pubHex, privHex, err := appCrypto.DeriveReceiveKey(seed, index)
+		if err == nil {
+			_, err = saveAddress(ctx, user, fmt.Sprintf("address-%d", index), index, pubHex, privHex)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save address"})
+			return
+		}
+	}
+
+	_, err = db.Col("users").UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
+		"$set": bson.M{
+			"public_key":         pubHex,
+			"encrypted_priv_key": encryptedPriv,
+			"encrypted_seed":     encryptedSeed,
+			"next_address_index": max(next, user.NextAddressIndex),
+			"updated_at":         time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		return
+	}
+
+	var total models.Amount
+	for _, f := range found {
+		total += f.Balance
+	}
+	logger.AddSystemLog(c, "wallet_restored",
+		fmt.Sprintf("wallet=%s addresses=%d recovered=%s", walletID, len(indexes), total))
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "wallet restored",
+		"wallet_id": walletID,
+		"addresses": found,
+		"balance":   total,
+	})
+}
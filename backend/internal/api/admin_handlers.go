@@ -6,15 +6,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type FundRequest struct {
-	WalletID string  `json:"wallet_id" binding:"required"`
-	Amount   float64 `json:"amount" binding:"required,gt=0"`
+	WalletID string        `json:"wallet_id" binding:"required"`
+	Amount   models.Amount `json:"amount" binding:"required,gt=0"`
 }
 
 // POST /api/admin/fund
@@ -37,7 +39,7 @@ func AdminFundWallet(c *gin.Context) {
 	}
 
 	// create a synthetic tx id for faucet
-	txID := "faucet-" + primitive.NewObjectID().Hex()
+	txID := utxo.FaucetTxPrefix + primitive.NewObjectID().Hex()
 
 	utxoCol := db.Col("utxos")
 	_, err := utxoCol.InsertOne(ctx, models.UTXO{
@@ -71,3 +73,14 @@ func AdminFundWallet(c *gin.Context) {
 		"amount":    req.Amount,
 	})
 }
+
+// GET /api/admin/chain/verify
+// Re-validates the whole stored chain and reports the first bad block, if any.
+func VerifyChainHandler(c *gin.Context) {
+	report, err := blockchain.VerifyChain(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "chain verification failed", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/rand"
@@ -28,6 +27,8 @@ type registerRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 	CNIC     string `json:"cnic" binding:"required"`
 	OTP      string `json:"otp" binding:"required"`
+	// optional BIP-39 passphrase; needed again to restore from the seed phrase
+	SeedPassphrase string `json:"seed_passphrase"`
 }
 
 type loginRequest struct {
@@ -123,8 +124,17 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// generate keypair (returns both as hex strings)
-	pubKeyHex, privKeyHex, err := appCrypto.GenerateKeyPair()
+	// generate a seed phrase; the wallet key is its first receive key
+	// (returns both as hex strings)
+	mnemonic, err := appCrypto.NewMnemonic()
+	var seed []byte
+	if err == nil {
+		seed, err = appCrypto.SeedFromMnemonic(mnemonic, req.SeedPassphrase)
+	}
+	var pubKeyHex, privKeyHex string
+	if err == nil {
+		pubKeyHex, privKeyHex, err = appCrypto.DeriveReceiveKey(seed, 0)
+	}
 	if err != nil {
 		logger.AddSystemLog(c,
 			"register_failed",
@@ -134,8 +144,12 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// encrypt private key (already hex, pass directly)
+	// encrypt private key (already hex, pass directly) and the seed
 	encryptedPriv, err := appCrypto.EncryptPrivateKey(privKeyHex)
+	var encryptedSeed string
+	if err == nil {
+		encryptedSeed, err = appCrypto.EncryptPrivateKey(hex.EncodeToString(seed))
+	}
 	if err != nil {
 		logger.AddSystemLog(c,
 			"register_failed",
@@ -146,8 +160,7 @@ func Register(c *gin.Context) {
 	}
 
 	// wallet id = SHA256(pubKeyHex)
-	h := sha256.Sum256([]byte(pubKeyHex))
-	walletID := hex.EncodeToString(h[:])
+	walletID := appCrypto.WalletIDFromPublicKeyHex(pubKeyHex)
 
 	now := time.Now().UTC()
 	user := models.User{
@@ -159,7 +172,9 @@ func Register(c *gin.Context) {
 		PasswordHash:     string(hashed),
 		PublicKey:        pubKeyHex,
 		EncryptedPrivKey: encryptedPriv,
-		Beneficiaries:    []string{},
+		EncryptedSeed:    encryptedSeed,
+		NextAddressIndex: 1,
+		Beneficiaries:    []models.Beneficiary{},
 		ZakatDeducted:    0,
 		CreatedAt:        now,
 		UpdatedAt:        now,
@@ -200,9 +215,12 @@ func Register(c *gin.Context) {
 		fmt.Sprintf("email=%s wallet=%s", user.Email, user.WalletID),
 	)
 
+	// the seed phrase is shown this once; it is the only way to rebuild the
+	// wallet if the stored keys are lost
 	c.JSON(http.StatusOK, gin.H{
-		"message": "registration successful",
-		"token":   token,
+		"message":  "registration successful",
+		"token":    token,
+		"mnemonic": mnemonic,
 		"user": gin.H{
 			"full_name":      user.FullName,
 			"email":          user.Email,
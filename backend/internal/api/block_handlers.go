@@ -2,22 +2,26 @@ package api
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/miner"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models" // 👈 here
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
 	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const miningRewardAmount = 50.0 // reward per mined block
-
 // GET /api/blocks
 func GetBlocks(c *gin.Context) {
 	ctx := context.Background()
@@ -39,193 +43,202 @@ func GetBlocks(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"blocks": blocks})
 }
 
-// POST /api/admin/mine
-// Mines a block with: 1) mining reward, 2) all pending user transactions.
-func MinePending(c *gin.Context) {
-	ctx := context.Background()
+type blockLink struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+}
 
-	minerWalletID := c.GetString("wallet_id")
-	if minerWalletID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing miner wallet in token"})
-		return
+type txTypeSummary struct {
+	Type        string        `json:"type"`
+	Count       int           `json:"count"`
+	TotalAmount models.Amount `json:"total_amount"`
+}
+
+// blockFilter resolves a block reference given as an index, a block hash
+// or a Mongo _id into a query filter.
+func blockFilter(id string) bson.M {
+	if idx, err := strconv.Atoi(id); err == nil && idx >= 0 {
+		return bson.M{"index": idx}
 	}
+	if len(id) == 64 {
+		if _, err := hex.DecodeString(id); err == nil {
+			return bson.M{"hash": id}
+		}
+	}
+	return db.ByID(id)
+}
 
+// GET /api/blocks/:id
+// :id may be the block index, its hash or its Mongo _id.
+func GetBlockByID(c *gin.Context) {
+	ctx := context.Background()
 	blocksCol := db.Col("blocks")
-	pendingCol := db.Col("pending_transactions")
-	utxoCol := db.Col("utxos")
-	txCol := db.Col("transactions")
 
-	// --- get last block (for index + prev hash) ---
-	var last models.Block
-	err := blocksCol.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&last)
-	hasPrev := err == nil
+	var block models.Block
+	if err := blocksCol.FindOne(ctx, blockFilter(c.Param("id"))).Decode(&block); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "block not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
 
-	// --- load all pending user transactions ---
-	cur, err := pendingCol.Find(ctx, bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error loading pending txs"})
+	var tip models.Block
+	if err := blocksCol.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&tip); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error loading chain tip"})
 		return
 	}
-	defer cur.Close(ctx)
 
-	var pendingTxs []models.Transaction
-	for cur.Next(ctx) {
-		var t models.Transaction
-		if err := cur.Decode(&t); err != nil {
-			continue
+	// per-type breakdown; coinbase value is new supply, not value moved
+	var summaries []txTypeSummary
+	byType := map[string]int{}
+	var totalValue, coinbaseReward models.Amount
+	for _, t := range block.Transactions {
+		i, ok := byType[t.Type]
+		if !ok {
+			i = len(summaries)
+			byType[t.Type] = i
+			summaries = append(summaries, txTypeSummary{Type: t.Type})
 		}
-		pendingTxs = append(pendingTxs, t)
-	}
+		summaries[i].Count++
+		summaries[i].TotalAmount += t.Amount
 
-	// --- create coinbase (mining reward) transaction ---
-	coinbaseID := primitive.NewObjectID().Hex()
-	coinbaseTx := models.Transaction{
-		ID:             coinbaseID,
-		SenderWallet:   "SYSTEM_COINBASE",
-		ReceiverWallet: minerWalletID,
-		Amount:         miningRewardAmount,
-		Note:           "Mining reward",
-		Type:           "mining_reward",
-		Status:         "confirmed",
-		Timestamp:      time.Now().UTC(),
+		if blockchain.IsCoinbase(t) {
+			coinbaseReward += t.Amount
+		} else {
+			totalValue += t.Amount
+		}
 	}
 
-	// --- assemble all block transactions: reward + pending ---
-	allTxs := make([]models.Transaction, 0, len(pendingTxs)+1)
-	allTxs = append(allTxs, coinbaseTx)
-	allTxs = append(allTxs, pendingTxs...)
-
-	// --- build block ---
-	block := models.Block{
-		Index:        0,
-		Timestamp:    time.Now().UTC(),
-		Transactions: allTxs,
-		PreviousHash: "",
+	var prevLink, nextLink *blockLink
+	if block.Index > 0 {
+		prevLink = &blockLink{Index: block.Index - 1, Hash: block.PreviousHash}
 	}
-	if hasPrev {
-		block.Index = last.Index + 1
-		block.PreviousHash = last.Hash
+	var next models.Block
+	if err := blocksCol.FindOne(ctx, bson.M{"index": block.Index + 1}).Decode(&next); err == nil {
+		nextLink = &blockLink{Index: next.Index, Hash: next.Hash}
 	}
 
-	// merkle + PoW
-	block.MerkleRoot = blockchain.MerkleRoot(block.Transactions)
-	blockchain.MineBlock(&block)
+	c.JSON(http.StatusOK, gin.H{
+		"block":           block,
+		"confirmations":   tip.Index - block.Index,
+		"tx_count":        len(block.Transactions),
+		"tx_summary":      summaries,
+		"total_value":     totalValue,
+		"coinbase_reward": coinbaseReward,
+		"previous_block":  prevLink,
+		"next_block":      nextLink,
+	})
+}
+
+// mineWaitTimeout is how long POST /api/admin/mine waits for the job to
+// finish before answering 202 and leaving it to run in the background.
+const mineWaitTimeout = 10 * time.Second
 
-	// --- insert block ---
-	_, err = blocksCol.InsertOne(ctx, block)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "block insert error"})
+// POST /api/admin/mine
+// Starts a background job that mines a block with: 1) mining reward plus
+// fees, 2) the best-paying mempool txs that still pass full validation.
+// Responds with the result if the block is found quickly, otherwise 202
+// with the job status; follow up with GET /api/admin/mining/status.
+func MinePending(c *gin.Context) {
+	minerWalletID := c.GetString("wallet_id")
+	if minerWalletID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing miner wallet in token"})
 		return
 	}
 
-	// --- 1) insert reward UTXO & tx ---
-	_, err = utxoCol.InsertOne(ctx, models.UTXO{
-		TxID:        coinbaseTx.ID,
-		OwnerWallet: minerWalletID,
-		Amount:      miningRewardAmount,
-		IsSpent:     false,
-	})
+	job, err := miner.Start(minerWalletID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to insert reward UTXO"})
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": miner.CurrentStatus()})
 		return
 	}
-	// save reward tx
-	coinbaseTx.Status = "confirmed"
-	_, _ = txCol.InsertOne(ctx, coinbaseTx)
-
-	// --- 2) process each pending transaction: UTXO spend + create ---
-	for _, t := range pendingTxs {
-		// 2.1 collect sender's available UTXOs
-		var senderUtxos []models.UTXO
-		uCur, err := utxoCol.Find(ctx, bson.M{
-			"owner_wallet": t.SenderWallet,
-			"is_spent":     false,
-		})
-		if err != nil {
-			continue
-		}
-		for uCur.Next(ctx) {
-			var u models.UTXO
-			if err := uCur.Decode(&u); err != nil {
-				continue
-			}
-			senderUtxos = append(senderUtxos, u)
-		}
-		uCur.Close(ctx)
-
-		// 2.2 pick enough UTXOs to cover tx.Amount
-		var used []models.UTXO
-		var total float64
-		for _, u := range senderUtxos {
-			used = append(used, u)
-			total += u.Amount
-			if total >= t.Amount {
-				break
-			}
-		}
 
-		// not enough balance at mining time? skip this tx
-		if total < t.Amount {
-			continue
-		}
+	select {
+	case <-job.Done():
+	case <-time.After(mineWaitTimeout):
+		c.JSON(http.StatusAccepted, gin.H{"message": "mining in progress", "status": miner.CurrentStatus()})
+		return
+	case <-c.Request.Context().Done():
+		return
+	}
 
-		// 2.3 mark used UTXOs as spent
-		for _, u := range used {
-			_, _ = utxoCol.UpdateOne(ctx,
-				bson.M{
-					"tx_id":        u.TxID,
-					"owner_wallet": u.OwnerWallet,
-					"amount":       u.Amount,
-					"is_spent":     false,
-				},
-				bson.M{"$set": bson.M{"is_spent": true}},
-			)
-		}
+	res := job.Result()
+	if res.Error != "" {
+		logger.AddSystemLog(c, "mine_failed", fmt.Sprintf("block #%d error=%s", res.BlockIndex, res.Error))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mine block", "details": res.Error})
+		return
+	}
 
-		// 2.4 create receiver UTXO
-		_, _ = utxoCol.InsertOne(ctx, models.UTXO{
-			TxID:        t.ID,
-			OwnerWallet: t.ReceiverWallet,
-			Amount:      t.Amount,
-			IsSpent:     false,
-		})
-
-		// 2.5 change UTXO back to sender if any leftover
-		change := total - t.Amount
-		if change > 0 {
-			_, _ = utxoCol.InsertOne(ctx, models.UTXO{
-				TxID:        t.ID,
-				OwnerWallet: t.SenderWallet,
-				Amount:      change,
-				IsSpent:     false,
-			})
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "block mined with reward and pending transactions",
+		"block_index":      res.BlockIndex,
+		"block_hash":       res.BlockHash,
+		"miner_wallet":     res.MinerWallet,
+		"reward_amount":    res.RewardAmount,
+		"fees_collected":   res.FeesCollected,
+		"tx_in_block":      res.TxInBlock,
+		"user_tx_mined":    res.UserTxMined,
+		"user_tx_rejected": res.UserTxRejected,
+		"user_tx_skipped":  res.UserTxSkipped,
+	})
+}
 
-		// 2.6 mark tx as confirmed and save to main transactions collection
-		t.Status = "confirmed"
-		_, _ = txCol.InsertOne(ctx, t)
+// GET /api/admin/mining/status
+func GetMiningStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, miner.CurrentStatus())
+}
+
+// POST /api/admin/mining/stop
+func StopMining(c *gin.Context) {
+	if !miner.Stop() {
+		c.JSON(http.StatusOK, gin.H{"message": "no mining job running"})
+		return
+	}
+	logger.AddSystemLog(c, "mining_stopped", fmt.Sprintf("by wallet=%s", c.GetString("wallet_id")))
+	c.JSON(http.StatusOK, gin.H{"message": "mining job stopping"})
+}
+
+// GET /api/chain/supply
+// Reports the monetary policy at the current tip and the circulating
+// supply: the value of every unspent output less admin faucet grants,
+// which are listed separately as faucet_supply.
+func GetSupply(c *gin.Context) {
+	ctx := context.Background()
+
+	height := -1
+	var tip models.Block
+	err := db.Col("blocks").FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&tip)
+	switch {
+	case err == nil:
+		height = tip.Index
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
 	}
 
-	// 2.7 clear pending pool (since we've processed what's possible)
-	_, _ = pendingCol.DeleteMany(ctx, bson.M{})
-	logger.AddSystemLog(
-		c,
-		"mined_block",
-		fmt.Sprintf("Block #%d mined by %s with reward %.4f, user_tx=%d",
-			block.Index,
-			minerWalletID,
-			miningRewardAmount,
-			len(pendingTxs),
-		),
-	)
+	unspent, utxoCount, err := utxo.TotalUnspent(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	// faucet grants are minted outside blocks and the subsidy schedule, so
+	// they are reported apart and the circulating supply stays under the cap
+	faucet, err := utxo.FaucetIssued(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "block mined with reward and pending transactions",
-		"block_index":   block.Index,
-		"block_hash":    block.Hash,
-		"miner_wallet":  minerWalletID,
-		"reward_amount": miningRewardAmount,
-		"tx_in_block":   len(allTxs),
-		"user_tx_mined": len(pendingTxs),
+		"height":              height,
+		"circulating_supply":  unspent - faucet,
+		"faucet_supply":       faucet,
+		"utxo_count":          utxoCount,
+		"max_supply":          config.AppConfig.MaxSupply,
+		"max_issued":          blockchain.IssuedThrough(height), // subsidy the schedule allowed up to the tip
+		"next_block_subsidy":  blockchain.Subsidy(height + 1),
+		"next_halving_height": blockchain.NextHalving(height + 1),
+		"halving_interval":    config.AppConfig.HalvingInterval,
 	})
 }
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/txbuilder"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type CreateHTLCRequest struct {
+	CreateTxRequest
+	// HashLock is the hex SHA-256 of a secret the receiver must reveal to claim
+	HashLock string `json:"hash_lock" binding:"required,len=64,hexadecimal"`
+	// Deadline is the block height from which the sender can refund instead
+	Deadline int `json:"deadline" binding:"required,gt=0"`
+}
+
+// POST /api/htlc
+// Locks funds to a receiver in a hash-time-locked output. The receiver
+// claims them by revealing the preimage of hash_lock before block
+// deadline; from then on only the sender can take them back.
+func CreateHTLC(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+
+	var req CreateHTLCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	if !walletExists(ctx, req.ReceiverWallet) || req.ReceiverWallet == walletID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid receiver wallet"})
+		return
+	}
+	height, err := blockchain.NextHeight(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	if req.Deadline <= height {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("deadline must be after the next block, height %d", height)})
+		return
+	}
+
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+	transfer, err := req.transfer()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	transfer.Type = "htlc"
+	transfer.HashLock = req.HashLock
+	transfer.Deadline = req.Deadline
+
+	tx, ok := sendCustodial(ctx, c, user, transfer)
+	if !ok {
+		return
+	}
+	logger.AddSystemLog(c, "htlc_created",
+		fmt.Sprintf("wallet=%s receiver=%s amount=%s deadline=%d tx=%s", walletID, req.ReceiverWallet, req.Amount, req.Deadline, tx.ID))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "HTLC created (pending mining)",
+		"tx_id":   tx.ID,
+		"htlc_id": models.OutpointID(tx.ID, 0),
+		"fee":     tx.Fee,
+	})
+}
+
+// GET /api/htlc
+// Lists open HTLCs the caller can claim or refund, with what it can do
+// with each at the next block.
+func ListHTLCs(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	height, err := blockchain.NextHeight(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	cur, err := db.Col("utxos").Find(ctx, bson.M{
+		"is_spent":  false,
+		"lock.type": models.LockHTLC,
+		"$or": bson.A{
+			bson.M{"lock.recipient": walletID},
+			bson.M{"lock.refund": walletID},
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	defer cur.Close(ctx)
+
+	type htlcView struct {
+		models.UTXO
+		Role   string `json:"role"`   // recipient or sender
+		Action string `json:"action"` // claim, refund or wait
+	}
+	out := []htlcView{}
+	for cur.Next(ctx) {
+		var u models.UTXO
+		if err := cur.Decode(&u); err != nil {
+			continue
+		}
+		v := htlcView{UTXO: u, Role: "sender", Action: "wait"}
+		expired := height >= u.Lock.Deadline
+		if u.Lock.Recipient == walletID {
+			v.Role = "recipient"
+			if !expired {
+				v.Action = "claim"
+			}
+		} else if expired {
+			v.Action = "refund"
+		}
+		out = append(out, v)
+	}
+	c.JSON(http.StatusOK, gin.H{"height": height, "htlcs": out})
+}
+
+type ClaimHTLCRequest struct {
+	Preimage string `json:"preimage" binding:"required,hexadecimal"`
+}
+
+// POST /api/htlc/:id/claim
+// Claims an HTLC paying the caller by revealing its preimage.
+func ClaimHTLC(c *gin.Context) {
+	var req ClaimHTLCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	spendHTLC(c, "htlc_claim", strings.ToLower(req.Preimage))
+}
+
+// POST /api/htlc/:id/refund
+// Takes back an expired HTLC the caller funded.
+func RefundHTLC(c *gin.Context) {
+	spendHTLC(c, "htlc_refund", "")
+}
+
+// spendHTLC builds, signs and submits a claim or refund of the HTLC
+// named by :id, checking the caller's side of it first so mistakes get a
+// clear error rather than a rejected tx.
+func spendHTLC(c *gin.Context, txType, preimage string) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var u models.UTXO
+	err := db.Col("utxos").FindOne(ctx, bson.M{"_id": c.Param("id"), "lock.type": models.LockHTLC}).Decode(&u)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "HTLC not found"})
+		return
+	}
+	if u.IsSpent {
+		c.JSON(http.StatusConflict, gin.H{"error": "HTLC already spent in tx " + u.SpentInTxID})
+		return
+	}
+	height, err := blockchain.NextHeight(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+
+	switch txType {
+	case "htlc_claim":
+		if u.Lock.Recipient != walletID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the recipient can claim this HTLC"})
+			return
+		}
+		if height >= u.Lock.Deadline {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("HTLC expired at height %d", u.Lock.Deadline)})
+			return
+		}
+		if hash, err := blockchain.HashPreimage(preimage); err != nil || hash != u.Lock.HashLock {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "preimage does not match the hash lock"})
+			return
+		}
+	case "htlc_refund":
+		if u.Lock.Refund != walletID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the sender can refund this HTLC"})
+			return
+		}
+		if height < u.Lock.Deadline {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("HTLC can be refunded from height %d", u.Lock.Deadline)})
+			return
+		}
+	}
+
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+	tx, err := txbuilder.BuildHTLCSpend(ctx, user, u, txType, preimage)
+	if err != nil {
+		buildFailed(c, err)
+		return
+	}
+	if err := txbuilder.SignWithCustodialKey(tx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := txbuilder.Submit(ctx, tx); err != nil {
+		if errors.Is(err, blockchain.ErrInvalidTx) || errors.Is(err, mempool.ErrConflict) || errors.Is(err, mempool.ErrDuplicate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+
+	logger.AddSystemLog(c, txType, fmt.Sprintf("wallet=%s htlc=%s tx=%s", walletID, u.ID, tx.ID))
+	c.JSON(http.StatusOK, gin.H{"message": txType + " submitted (pending mining)", "tx_id": tx.ID, "amount": tx.Amount, "fee": tx.Fee})
+}
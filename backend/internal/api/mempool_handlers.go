@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// GET /api/mempool
+// Lists pending transactions in the order the miner would pick them.
+func GetMempool(c *gin.Context) {
+	entries := mempool.Snapshot()
+
+	var totalFees models.Amount
+	totalBytes := 0
+	for _, e := range entries {
+		totalFees += e.Fee
+		totalBytes += e.Size
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":           len(entries),
+		"total_bytes":     totalBytes,
+		"total_fees":      totalFees,
+		"max_block_txs":   config.AppConfig.MaxBlockTxs,
+		"max_block_bytes": config.AppConfig.MaxBlockBytes,
+		"fee_rate":        mempool.EstimateFeeRate(), // per 1000 bytes to make the next block
+		"entries":         entries,
+	})
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/txbuilder"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CreateMultisigRequest struct {
+	Name      string `json:"name"`
+	Threshold int    `json:"threshold" binding:"required,gte=1"`
+	// Members are wallet ids of registered users; the caller is always one
+	Members []string `json:"members" binding:"required,max=15"`
+}
+
+// POST /api/multisig
+// Creates an M-of-N wallet shared by the caller and the given members.
+// Its id derives from the sorted member public keys and the threshold, so
+// the same policy always maps to the same wallet.
+func CreateMultisigWallet(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var req CreateMultisigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	memberIDs := []string{walletID}
+	seen := map[string]bool{walletID: true}
+	for _, m := range req.Members {
+		m = strings.TrimSpace(m)
+		if m != "" && !seen[m] {
+			seen[m] = true
+			memberIDs = append(memberIDs, m)
+		}
+	}
+	if len(memberIDs) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a multisig wallet needs at least two members"})
+		return
+	}
+	if req.Threshold > len(memberIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("threshold %d exceeds %d members", req.Threshold, len(memberIDs))})
+		return
+	}
+
+	// member wallet id by public key, to list members in key order
+	byKey := map[string]string{}
+	keys := make([]string, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		member, err := loadUser(ctx, id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "member wallet " + id + " is not a registered user"})
+			return
+		}
+		key := strings.ToLower(member.PublicKey)
+		byKey[key] = id
+		keys = append(keys, key)
+	}
+	keys = appCrypto.SortPublicKeys(keys)
+	members := make([]string, len(keys))
+	for i, k := range keys {
+		members[i] = byKey[k]
+	}
+
+	msID := appCrypto.MultisigWalletID(req.Threshold, keys)
+	ms := models.MultisigWallet{
+		ID:             msID,
+		WalletID:       msID,
+		Name:           strings.TrimSpace(req.Name),
+		MultisigPolicy: models.MultisigPolicy{Threshold: req.Threshold, PublicKeys: keys},
+		Members:        members,
+		CreatedBy:      walletID,
+		CreatedAt:      time.Now().UTC(),
+	}
+	// a wallets doc lets the multisig wallet receive like any other; both
+	// are written together so a failure can't leave a policy without one
+	err := db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := db.Col("multisig_wallets").InsertOne(sc, ms); err != nil {
+			return err
+		}
+		_, err := db.Col("wallets").InsertOne(sc, models.Wallet{WalletID: msID, Type: "multisig"})
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// repair a policy stored without its wallets doc by older versions
+		_, err = db.Col("wallets").UpdateOne(ctx,
+			bson.M{"wallet_id": msID},
+			bson.M{"$setOnInsert": models.Wallet{ID: primitive.NewObjectID().Hex(), WalletID: msID, Type: "multisig"}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create wallet"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "a wallet with this policy already exists", "wallet_id": msID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create wallet"})
+		return
+	}
+
+	logger.AddSystemLog(c, "multisig_created",
+		fmt.Sprintf("wallet=%s multisig=%s policy=%d-of-%d", walletID, msID, req.Threshold, len(keys)))
+	c.JSON(http.StatusCreated, ms)
+}
+
+// GET /api/multisig
+// Lists the multisig wallets the caller is a member of.
+func ListMultisigWallets(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	cur, err := db.Col("multisig_wallets").Find(ctx, bson.M{"members": walletID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	defer cur.Close(ctx)
+
+	out := []models.MultisigWallet{}
+	if err := cur.All(ctx, &out); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "decode error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wallets": out})
+}
+
+// GET /api/multisig/:id
+func GetMultisigWallet(c *gin.Context) {
+	ctx := context.Background()
+
+	ms, ok := findMultisig(ctx, c)
+	if !ok {
+		return
+	}
+	balances, err := utxo.GetBalances(ctx, ms.WalletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "balance error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wallet": ms, "balances": balances})
+}
+
+// POST /api/multisig/:id/psbt
+// Builds an unsigned spend from the multisig wallet for its members to
+// sign. Takes the same body as POST /api/tx. The tx is timestamped now,
+// so it must be finalized within the mempool max age.
+func CreatePartialTx(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	ms, ok := findMultisig(ctx, c)
+	if !ok {
+		return
+	}
+
+	var req CreateTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !walletExists(ctx, req.ReceiverWallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid receiver wallet"})
+		return
+	}
+	t, err := req.transfer()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t.Multisig = &ms.MultisigPolicy
+
+	tx, _, err := txbuilder.Build(ctx, models.User{WalletID: ms.WalletID}, t)
+	if err != nil {
+		buildFailed(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	ptx := models.PartialTx{
+		ID:        tx.ID,
+		WalletID:  ms.WalletID,
+		Tx:        *tx,
+		Status:    "open",
+		CreatedBy: walletID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := db.Col("partial_transactions").InsertOne(ctx, ptx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusCreated, ptx)
+}
+
+// GET /api/multisig/:id/psbt
+// Lists the wallet's spends still collecting signatures.
+func ListPartialTxs(c *gin.Context) {
+	ctx := context.Background()
+
+	ms, ok := findMultisig(ctx, c)
+	if !ok {
+		return
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := db.Col("partial_transactions").Find(ctx, bson.M{"wallet_id": ms.WalletID, "status": "open"}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	defer cur.Close(ctx)
+
+	out := []models.PartialTx{}
+	if err := cur.All(ctx, &out); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "decode error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"transactions": out})
+}
+
+type SignPartialTxRequest struct {
+	// Signature over the tx's signing payload made outside the server;
+	// the caller's custodial key signs when it is empty
+	Signature string `json:"signature"`
+}
+
+// POST /api/psbt/:id/sign
+// Adds the caller's signature to an open multisig spend.
+func SignPartialTx(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var req SignPartialTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ptx, ok := findPartialTx(ctx, c)
+	if !ok {
+		return
+	}
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+	tx := ptx.Tx
+	i := tx.Multisig.IndexOf(strings.ToLower(user.PublicKey))
+	if i < 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a member of this wallet"})
+		return
+	}
+
+	if req.Signature != "" {
+		err = blockchain.VerifyMemberSignature(tx.Multisig.PublicKeys[i], blockchain.SigningPayload(tx), req.Signature)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tx.Multisig.Signatures[i] = req.Signature
+	} else if err := txbuilder.SignAsMember(&tx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := db.Col("partial_transactions").UpdateOne(ctx,
+		bson.M{"_id": ptx.ID, "status": "open"},
+		bson.M{"$set": bson.M{
+			fmt.Sprintf("tx.multisig.signatures.%d", i): tx.Multisig.Signatures[i],
+			"updated_at": time.Now().UTC(),
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction is no longer open"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "signature added",
+		"signatures": tx.Multisig.SignatureCount(),
+		"threshold":  tx.Multisig.Threshold,
+	})
+}
+
+// POST /api/psbt/:id/finalize
+// Submits a multisig spend to the mempool once enough members signed.
+func FinalizePartialTx(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	ptx, ok := findPartialTx(ctx, c)
+	if !ok {
+		return
+	}
+	tx := ptx.Tx
+	if n := tx.Multisig.SignatureCount(); n < tx.Multisig.Threshold {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%d of %d required signatures", n, tx.Multisig.Threshold)})
+		return
+	}
+
+	if err := txbuilder.Submit(ctx, &tx); err != nil {
+		if errors.Is(err, blockchain.ErrInvalidTx) || errors.Is(err, mempool.ErrConflict) || errors.Is(err, mempool.ErrDuplicate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	_, _ = db.Col("partial_transactions").UpdateOne(ctx,
+		bson.M{"_id": ptx.ID},
+		bson.M{"$set": bson.M{"status": "finalized", "updated_at": time.Now().UTC()}},
+	)
+
+	logger.AddSystemLog(c, "multisig_finalized",
+		fmt.Sprintf("wallet=%s multisig=%s tx=%s", walletID, ptx.WalletID, tx.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "transaction submitted (pending mining)", "tx_id": tx.ID})
+}
+
+// findMultisig loads the wallet named by :id if the caller is a member,
+// writing the error response otherwise.
+func findMultisig(ctx context.Context, c *gin.Context) (models.MultisigWallet, bool) {
+	var ms models.MultisigWallet
+	filter := bson.M{"wallet_id": c.Param("id"), "members": c.GetString("wallet_id")}
+	if err := db.Col("multisig_wallets").FindOne(ctx, filter).Decode(&ms); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "multisig wallet not found"})
+		return ms, false
+	}
+	return ms, true
+}
+
+// findPartialTx loads the open spend named by :id if the caller is a
+// member of its wallet, writing the error response otherwise.
+func findPartialTx(ctx context.Context, c *gin.Context) (models.PartialTx, bool) {
+	var ptx models.PartialTx
+	if err := db.Col("partial_transactions").FindOne(ctx, bson.M{"_id": c.Param("id")}).Decode(&ptx); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return ptx, false
+	}
+	err := db.Col("multisig_wallets").FindOne(ctx, bson.M{"wallet_id": ptx.WalletID, "members": c.GetString("wallet_id")}).Err()
+	if err != nil || ptx.Tx.Multisig == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return ptx, false
+	}
+	if ptx.Status != "open" {
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction is " + ptx.Status})
+		return ptx, false
+	}
+	return ptx, true
+}
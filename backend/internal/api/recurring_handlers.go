@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/scheduler"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RecurringRequest struct {
+	ReceiverWallet string         `json:"receiver_wallet" binding:"required"`
+	Amount         models.Amount  `json:"amount" binding:"required"`
+	Fee            *models.Amount `json:"fee"`
+	Note           string         `json:"note"`
+	Schedule       string         `json:"schedule" binding:"required"` // cron expression or @daily, @weekly, @monthly
+	EndAt          *time.Time     `json:"end_at"`
+}
+
+// POST /api/recurring
+func CreateRecurring(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	var req RecurringRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.ReceiverWallet = strings.TrimSpace(req.ReceiverWallet)
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+	if req.Fee != nil && *req.Fee < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fee must not be negative"})
+		return
+	}
+	if req.ReceiverWallet == walletID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot schedule transfers to your own wallet"})
+		return
+	}
+	if !walletExists(ctx, req.ReceiverWallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "receiver wallet does not exist"})
+		return
+	}
+
+	sched, err := scheduler.ParseSchedule(req.Schedule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule: " + err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	next := sched.Next(now)
+	if next.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "schedule never runs"})
+		return
+	}
+	if req.EndAt != nil && next.After(*req.EndAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_at is before the first run"})
+		return
+	}
+
+	rt := models.RecurringTransfer{
+		ID:             primitive.NewObjectID(),
+		OwnerWallet:    walletID,
+		ReceiverWallet: req.ReceiverWallet,
+		Amount:         req.Amount,
+		Fee:            req.Fee,
+		Note:           req.Note,
+		Schedule:       strings.TrimSpace(req.Schedule),
+		EndAt:          req.EndAt,
+		NextRunAt:      next,
+		Status:         "active",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if _, err := db.Col("recurring_transfers").InsertOne(ctx, rt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save schedule"})
+		return
+	}
+
+	logger.AddSystemLog(c, "recurring_created",
+		fmt.Sprintf("wallet=%s id=%s receiver=%s amount=%s schedule=%q", walletID, rt.ID.Hex(), rt.ReceiverWallet, rt.Amount, rt.Schedule))
+	c.JSON(http.StatusCreated, rt)
+}
+
+// GET /api/recurring
+func ListRecurring(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := db.Col("recurring_transfers").Find(ctx, bson.M{"owner_wallet": walletID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	defer cur.Close(ctx)
+
+	out := []models.RecurringTransfer{}
+	if err := cur.All(ctx, &out); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "decode error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recurring": out})
+}
+
+// GET /api/recurring/:id/runs
+func GetRecurringRuns(c *gin.Context) {
+	ctx := context.Background()
+
+	rt, ok := findRecurring(ctx, c)
+	if !ok {
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "due_at", Value: -1}}).SetLimit(100)
+	cur, err := db.Col("recurring_runs").Find(ctx, bson.M{"schedule_id": rt.ID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	defer cur.Close(ctx)
+
+	runs := []models.RecurringRun{}
+	if err := cur.All(ctx, &runs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "decode error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedule": rt, "runs": runs})
+}
+
+// POST /api/recurring/:id/pause
+func PauseRecurring(c *gin.Context) {
+	setRecurringStatus(c, "active", "paused")
+}
+
+// POST /api/recurring/:id/resume
+func ResumeRecurring(c *gin.Context) {
+	setRecurringStatus(c, "paused", "active")
+}
+
+// POST /api/recurring/:id/cancel
+func CancelRecurring(c *gin.Context) {
+	setRecurringStatus(c, "", "cancelled")
+}
+
+// findRecurring loads the schedule named by :id if it belongs to the
+// caller, writing the error response otherwise.
+func findRecurring(ctx context.Context, c *gin.Context) (models.RecurringTransfer, bool) {
+	var rt models.RecurringTransfer
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return rt, false
+	}
+	filter := bson.M{"_id": id, "owner_wallet": c.GetString("wallet_id")}
+	if err := db.Col("recurring_transfers").FindOne(ctx, filter).Decode(&rt); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return rt, false
+	}
+	return rt, true
+}
+
+// setRecurringStatus moves a schedule from status from ("" for any live
+// status) to status to. Resuming skips the runs missed while paused.
+func setRecurringStatus(c *gin.Context, from, to string) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+
+	rt, ok := findRecurring(ctx, c)
+	if !ok {
+		return
+	}
+	live := rt.Status == "active" || rt.Status == "paused"
+	if (from == "" && !live) || (from != "" && rt.Status != from) {
+		c.JSON(http.StatusConflict, gin.H{"error": "schedule is " + rt.Status})
+		return
+	}
+
+	now := time.Now().UTC()
+	set := bson.M{"status": to, "updated_at": now}
+	if to == "active" {
+		sched, err := scheduler.ParseSchedule(rt.Schedule)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "stored schedule is invalid"})
+			return
+		}
+		next := sched.Next(now)
+		if next.IsZero() || (rt.EndAt != nil && next.After(*rt.EndAt)) {
+			c.JSON(http.StatusConflict, gin.H{"error": "schedule has no runs left"})
+			return
+		}
+		set["next_run_at"] = next
+	}
+
+	res, err := db.Col("recurring_transfers").UpdateOne(ctx,
+		bson.M{"_id": rt.ID, "status": rt.Status},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update schedule"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "schedule changed, try again"})
+		return
+	}
+
+	logger.AddSystemLog(c, "recurring_"+to, fmt.Sprintf("wallet=%s id=%s", walletID, rt.ID.Hex()))
+	c.JSON(http.StatusOK, gin.H{"message": "schedule " + to})
+}
@@ -6,12 +6,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 type reportTotals struct {
-	Total float64 `bson:"total" json:"total"`
-	Count int64   `bson:"count" json:"count"`
+	Total models.Amount `bson:"total" json:"total"`
+	Count int64         `bson:"count" json:"count"`
 }
 
 // GET /api/reports/summary
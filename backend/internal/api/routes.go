@@ -1,11 +1,16 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/middleware"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/zakat"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
 )
 
 func RegisterRoutes(r *gin.Engine) {
@@ -27,16 +32,60 @@ func RegisterRoutes(r *gin.Engine) {
 	protected.GET("/wallet", GetWalletProfile)
 	protected.GET("/wallet/balance", GetBalance)
 	protected.GET("/wallet/utxos", GetUTXOs)
+	protected.GET("/wallet/beneficiaries", GetBeneficiaries)
 	protected.POST("/wallet/beneficiaries", UpdateBeneficiaries)
+	protected.PUT("/wallet/beneficiaries/:name", UpsertBeneficiary)
+	protected.DELETE("/wallet/beneficiaries/:name", DeleteBeneficiary)
+	protected.PUT("/wallet/transfer-policy", UpdateTransferPolicy)
+	protected.GET("/wallet/addresses", ListAddresses)
+	protected.POST("/wallet/addresses", CreateAddress)
+	protected.PUT("/wallet/addresses/:id", RenameAddress)
+	protected.POST("/wallet/restore", RestoreWallet)
 
 	// Transactions
 	protected.POST("/tx", CreateTransaction)
+	protected.POST("/tx/batch", CreateBatchTransaction)
+	protected.POST("/tx/to-beneficiary/:name", PayBeneficiary)
+	protected.GET("/tx/unsigned", GetUnsignedTransaction)
+	protected.GET("/tx/dry-run", DryRunTransaction)
+	protected.POST("/tx/raw", SubmitRawTransaction)
 	protected.GET("/tx/history", GetTxHistory)
+	protected.GET("/tx/:id/proof", GetTxProof)
+
+	// Hash-time-locked transfers (escrow)
+	protected.POST("/htlc", CreateHTLC)
+	protected.GET("/htlc", ListHTLCs)
+	protected.POST("/htlc/:id/claim", ClaimHTLC)
+	protected.POST("/htlc/:id/refund", RefundHTLC)
+
+	// Multisig wallets and their partially signed transactions
+	protected.POST("/multisig", CreateMultisigWallet)
+	protected.GET("/multisig", ListMultisigWallets)
+	protected.GET("/multisig/:id", GetMultisigWallet)
+	protected.POST("/multisig/:id/psbt", CreatePartialTx)
+	protected.GET("/multisig/:id/psbt", ListPartialTxs)
+	protected.POST("/psbt/:id/sign", SignPartialTx)
+	protected.POST("/psbt/:id/finalize", FinalizePartialTx)
+
+	// Recurring transfers
+	protected.POST("/recurring", CreateRecurring)
+	protected.GET("/recurring", ListRecurring)
+	protected.GET("/recurring/:id/runs", GetRecurringRuns)
+	protected.POST("/recurring/:id/pause", PauseRecurring)
+	protected.POST("/recurring/:id/resume", ResumeRecurring)
+	protected.POST("/recurring/:id/cancel", CancelRecurring)
+
+	// Mempool
+	protected.GET("/mempool", GetMempool)
 
 	// Blockchain
 	protected.GET("/blocks", GetBlocks)
 	protected.GET("/blocks/:id", GetBlockByID)
+	protected.GET("/chain/supply", GetSupply)
 	protected.POST("/admin/mine", MinePending)
+	protected.GET("/admin/mining/status", GetMiningStatus)
+	protected.POST("/admin/mining/stop", StopMining)
+	protected.GET("/admin/chain/verify", VerifyChainHandler)
 
 	// Zakat
 	protected.POST("/zakat/run-self", RunSelfZakatHandler)
@@ -47,14 +96,9 @@ func RegisterRoutes(r *gin.Engine) {
 	protected.GET("/logs/transactions", GetTxLogs)
 }
 
-// RunZakatNowHandler handles POST /admin/run-zakat requests.
-// RunZakatNowHandler handles POST /api/admin/run-zakat
-// It creates pending zakat_deduction transactions for all wallets
-// and returns how many were created. To confirm them on-chain,
-// you then mine pending txs via /api/admin/mine.
 // RunSelfZakatHandler handles POST /api/zakat/run-self.
-// It runs zakat (2.5%) for the CURRENT logged-in wallet and creates
-// a "zakat_deduction" transaction with status "pending".
+// It submits a signed zakat_deduction (2.5%) transaction for the current
+// wallet to the mempool; it is confirmed when pending txs are mined.
 func RunSelfZakatHandler(c *gin.Context) {
 	walletID := c.GetString("wallet_id")
 	if walletID == "" {
@@ -72,20 +116,15 @@ func RunSelfZakatHandler(c *gin.Context) {
 		return
 	}
 
-	txDoc, err := zakat.RunZakatForWallet(c, walletID)
-	if err != nil {
-		// soft errors → 200
-		if err.Error() == "no UTXOs / no balance, zakat not due" ||
-			err.Error() == "balance <= 0, zakat not due" ||
-			err.Error() == "zakat computed as 0, nothing to do" ||
-			err.Error() == "not enough UTXOs to cover zakat" {
-			c.JSON(http.StatusOK, gin.H{
-				"message": err.Error(),
-			})
-			return
-		}
-
-		// real errors → 500
+	tx, err := zakat.RunZakatForWallet(context.Background(), walletID)
+	switch {
+	case errors.Is(err, zakat.ErrNotDue):
+		c.JSON(http.StatusOK, gin.H{"message": "No zakat due for this wallet."})
+		return
+	case errors.Is(err, utxo.ErrInsufficientFunds):
+		c.JSON(http.StatusOK, gin.H{"message": "not enough spendable funds to cover zakat"})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "failed to run zakat",
 			"details": err.Error(),
@@ -93,22 +132,11 @@ func RunSelfZakatHandler(c *gin.Context) {
 		return
 	}
 
-	if txDoc == nil {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "No zakat due for this wallet.",
-		})
-		return
-	}
+	logger.AddSystemLog(c, "zakat_created",
+		fmt.Sprintf("wallet=%s amount=%s tx=%s", walletID, tx.Amount, tx.ID))
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Zakat transaction created as pending. Mine pending transactions to confirm.",
-		"tx":      txDoc,
+		"tx":      tx,
 	})
 }
-
-// GetBlockByID handles GET /blocks/:id requests.
-func GetBlockByID(c *gin.Context) {
-	id := c.Param("id")
-	// TODO: Implement logic to fetch block by ID
-	c.JSON(http.StatusOK, gin.H{"message": "Block details for ID " + id})
-}
@@ -2,22 +2,59 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
 	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/hdwallet"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/txbuilder"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateTxRequest struct {
-	ReceiverWallet string  `json:"receiver_wallet" binding:"required"`
-	Amount         float64 `json:"amount" binding:"required,gt=0"`
-	Note           string  `json:"note"`
+	ReceiverWallet string        `json:"receiver_wallet" form:"receiver_wallet" binding:"required"`
+	Amount         models.Amount `json:"amount" form:"amount" binding:"required,gt=0"`
+	Note           string        `json:"note" form:"note"`
+	// Fee paid to the miner; estimated from mempool pressure when omitted
+	Fee *models.Amount `json:"fee" form:"fee" binding:"omitempty,gte=0"`
+	// Strategy is the coin selection to use, see utxo.Strategies
+	Strategy string `json:"strategy" form:"strategy"`
+	// LockTime delays mining until a block height, or a unix time from 500000000 on
+	LockTime int64 `json:"lock_time" form:"lock_time" binding:"gte=0"`
+	// MaturityHeight is the first block height that may spend the payment
+	MaturityHeight int `json:"maturity_height" form:"maturity_height" binding:"gte=0"`
+}
+
+// transfer turns the request into a txbuilder.Transfer.
+func (r CreateTxRequest) transfer() (txbuilder.Transfer, error) {
+	strategy, err := utxo.ParseStrategy(r.Strategy)
+	if err != nil {
+		return txbuilder.Transfer{}, err
+	}
+	t := txbuilder.Transfer{
+		Receiver:       r.ReceiverWallet,
+		Amount:         r.Amount,
+		Note:           r.Note,
+		Strategy:       strategy,
+		LockTime:       r.LockTime,
+		MaturityHeight: r.MaturityHeight,
+	}
+	if r.Fee != nil {
+		t.Fee = *r.Fee
+	} else {
+		t.AutoFee = true
+	}
+	return t, nil
 }
 
 // in api/tx_handlers.go
@@ -25,15 +62,25 @@ func walletExists(ctx context.Context, walletID string) bool {
 	err := db.Col("wallets").FindOne(ctx, bson.M{"wallet_id": walletID}).Err()
 	return err == nil
 }
+
+// GET /api/tx/history
+// Lists txs touching any of the caller's addresses, or the one named by
+// ?address=, with per-address counts and amounts sent and received.
 func GetTxHistory(c *gin.Context) {
-	walletID := c.GetString("wallet_id")
 	ctx := context.Background()
 
+	_, addrs, ok := selectAddresses(ctx, c)
+	if !ok {
+		return
+	}
+	ids := walletIDs(addrs)
+
 	col := db.Col("transactions")
 	filter := bson.M{
 		"$or": []bson.M{
-			{"sender_wallet": walletID},
-			{"receiver_wallet": walletID},
+			{"sender_wallet": bson.M{"$in": ids}},
+			{"receiver_wallet": bson.M{"$in": ids}},
+			{"outputs.owner_wallet": bson.M{"$in": ids}}, // batch payments
 		},
 	}
 	cur, err := col.Find(ctx, filter)
@@ -43,6 +90,20 @@ func GetTxHistory(c *gin.Context) {
 	}
 	defer cur.Close(ctx)
 
+	type addressActivity struct {
+		WalletID string        `json:"wallet_id"`
+		Label    string        `json:"label"`
+		TxCount  int           `json:"tx_count"`
+		Sent     models.Amount `json:"sent"`     // payments and fees of txs it funded
+		Received models.Amount `json:"received"` // outputs paid to it by other wallets
+	}
+	breakdown := make([]addressActivity, len(addrs))
+	byWallet := make(map[string]*addressActivity, len(addrs))
+	for i, a := range addrs {
+		breakdown[i] = addressActivity{WalletID: a.WalletID, Label: a.Label}
+		byWallet[a.WalletID] = &breakdown[i]
+	}
+
 	var txs []models.Transaction
 	for cur.Next(ctx) {
 		var t models.Transaction
@@ -50,15 +111,48 @@ func GetTxHistory(c *gin.Context) {
 			continue
 		}
 		txs = append(txs, t)
+
+		touched := map[string]bool{}
+		if a, ok := byWallet[t.SenderWallet]; ok {
+			a.Sent += t.Amount + t.Fee
+			touched[t.SenderWallet] = true
+		}
+		if a, ok := byWallet[t.ReceiverWallet]; ok {
+			touched[t.ReceiverWallet] = true
+			if len(t.Outputs) == 0 && t.SenderWallet != t.ReceiverWallet {
+				a.Received += t.Amount // legacy tx without outputs
+			}
+		}
+		for _, o := range t.Outputs {
+			if a, ok := byWallet[o.OwnerWallet]; ok {
+				touched[o.OwnerWallet] = true
+				if o.OwnerWallet != t.SenderWallet {
+					a.Received += o.Amount
+				}
+			}
+		}
+		for w := range touched {
+			byWallet[w].TxCount++
+		}
 	}
-	c.JSON(http.StatusOK, gin.H{"transactions": txs})
+	c.JSON(http.StatusOK, gin.H{"transactions": txs, "addresses": breakdown})
+}
+
+// TransferTxRequest is a CreateTxRequest that may pay from any of the
+// caller's addresses.
+type TransferTxRequest struct {
+	CreateTxRequest
+	// From names the paying address by wallet id or label; the main wallet
+	// when empty. Change returns to it.
+	From string `json:"from"`
 }
 
+// POST /api/tx
 func CreateTransaction(c *gin.Context) {
 	// user from JWT middleware
 	walletID := c.GetString("wallet_id")
 
-	var req CreateTxRequest
+	var req TransferTxRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -70,81 +164,493 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	// UTXO selection
-	selected, change, err := utxo.SelectUTXOsForAmount(ctx, walletID, req.Amount)
+	// load sender keys from DB
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+	addrs, err := userAddresses(ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	from, ok := findAddress(addrs, req.From)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown spending address " + req.From})
+		return
+	}
+
+	transfer, err := req.transfer()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, ok := sendCustodial(ctx, c, from.Sender(user), transfer)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "transaction created (pending mining)", "tx_id": tx.ID, "from": from.WalletID, "fee": tx.Fee})
+}
+
+// checkTransferPolicy applies the sender's beneficiaries-only setting to
+// every payment of t.
+func checkTransferPolicy(ctx context.Context, user models.User, t txbuilder.Transfer) error {
+	return checkOutgoing(ctx, user, t.Outputs())
+}
+
+// checkOutgoing applies the sender's beneficiaries-only setting to the
+// total each receiver gets, so splitting a payment into many small outputs
+// to one wallet doesn't get around the threshold. Payments between the
+// user's own addresses are always allowed.
+func checkOutgoing(ctx context.Context, user models.User, payments []txbuilder.Payment) error {
+	totals := map[string]models.Amount{}
+	var receivers []string
+	for _, p := range payments {
+		if _, ok := totals[p.Receiver]; !ok {
+			receivers = append(receivers, p.Receiver)
+		}
+		total, err := models.AddAmounts(totals[p.Receiver], p.Amount, math.MaxInt64)
+		if err != nil {
+			return fmt.Errorf("%w: payments to %s: %w", blockchain.ErrInvalidTx, p.Receiver, err)
+		}
+		totals[p.Receiver] = total
+	}
+	if !user.BeneficiaryOnly {
+		return nil
+	}
+	owned, err := hdwallet.OwnedWallets(ctx, user)
 	if err != nil {
+		return err
+	}
+	for _, r := range receivers {
+		if err := user.CheckOutgoing(r, totals[r], owned); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildFailed writes the response for a txbuilder.Build error.
+func buildFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, utxo.ErrInsufficientFunds):
 		c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient balance"})
+	case errors.Is(err, blockchain.ErrInvalidTx):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build transaction"})
+	}
+}
+
+// sendCustodial builds t, signs it with the user's server-held key and
+// submits it to the mempool. On failure it writes the error response and
+// returns false.
+func sendCustodial(ctx context.Context, c *gin.Context, user models.User, t txbuilder.Transfer) (*models.Transaction, bool) {
+	if err := checkTransferPolicy(ctx, user, t); err != nil {
+		switch {
+		case errors.Is(err, blockchain.ErrInvalidTx):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrBeneficiaryOnly):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		}
+		return nil, false
+	}
+
+	// UTXO selection
+	tx, _, err := txbuilder.Build(ctx, user, t)
+	if err != nil {
+		buildFailed(c, err)
+		return nil, false
+	}
+
+	if err := txbuilder.SignWithCustodialKey(tx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if err := txbuilder.Submit(ctx, tx); err != nil {
+		if errors.Is(err, blockchain.ErrInvalidTx) || errors.Is(err, mempool.ErrConflict) || errors.Is(err, mempool.ErrDuplicate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return nil, false
+	}
+	return tx, true
+}
+
+type BeneficiaryTxRequest struct {
+	Amount   *models.Amount `json:"amount" binding:"omitempty,gt=0"` // defaults to the contact's default amount
+	Note     string         `json:"note"`
+	Fee      *models.Amount `json:"fee" binding:"omitempty,gte=0"`
+	Strategy string         `json:"strategy"`
+}
+
+// POST /api/tx/to-beneficiary/:name
+// Pays a saved contact by nickname, using its default amount unless the
+// body gives one.
+func PayBeneficiary(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+
+	var req BeneficiaryTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+	b, ok := user.FindBeneficiary(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "beneficiary not found"})
 		return
 	}
 
-	// build payload for signing
-	timestamp := time.Now().UTC()
-	payload := walletID + req.ReceiverWallet + fmt.Sprintf("%f", req.Amount) + timestamp.Format(time.RFC3339) + req.Note
+	amount := b.DefaultAmount
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount required: beneficiary has no default amount"})
+		return
+	}
+
+	transfer, err := CreateTxRequest{
+		ReceiverWallet: b.WalletID,
+		Amount:         amount,
+		Note:           req.Note,
+		Fee:            req.Fee,
+		Strategy:       req.Strategy,
+	}.transfer()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, ok := sendCustodial(ctx, c, user, transfer)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "transaction created (pending mining)",
+		"tx_id":       tx.ID,
+		"beneficiary": b.Name,
+		"amount":      tx.Amount,
+		"fee":         tx.Fee,
+	})
+}
+
+type BatchRecipient struct {
+	ReceiverWallet string        `json:"receiver_wallet" binding:"required"`
+	Amount         models.Amount `json:"amount" binding:"required,gt=0"`
+}
+
+// BatchTxRequest pays up to 500 recipients in one transaction.
+type BatchTxRequest struct {
+	Recipients []BatchRecipient `json:"recipients" binding:"required,min=1,max=500,dive"`
+	Note       string           `json:"note"`
+	Fee        *models.Amount   `json:"fee" binding:"omitempty,gte=0"`
+	Strategy   string           `json:"strategy"`
+}
+
+// POST /api/tx/batch
+// Pays many wallets from one signed transaction with one output per
+// recipient (plus change), e.g. for payroll.
+func CreateBatchTransaction(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+
+	var req BatchTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	strategy, err := utxo.ParseStrategy(req.Strategy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	transfer := txbuilder.Transfer{
+		Note:     req.Note,
+		Type:     "batch",
+		Strategy: strategy,
+		AutoFee:  req.Fee == nil,
+	}
+	if req.Fee != nil {
+		transfer.Fee = *req.Fee
+	}
+	for i, r := range req.Recipients {
+		if !walletExists(ctx, r.ReceiverWallet) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("recipient #%d: invalid receiver wallet %s", i, r.ReceiverWallet)})
+			return
+		}
+		transfer.Payments = append(transfer.Payments, txbuilder.Payment{Receiver: r.ReceiverWallet, Amount: r.Amount})
+	}
+
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
+	}
+
+	tx, ok := sendCustodial(ctx, c, user, transfer)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "batch transaction created (pending mining)",
+		"tx_id":      tx.ID,
+		"recipients": len(req.Recipients),
+		"total":      tx.Amount,
+		"fee":        tx.Fee,
+		"outputs":    tx.Outputs,
+	})
+}
+
+// GET /api/tx/unsigned?receiver_wallet=...&amount=...&note=...&fee=...&strategy=...
+// Builds a transfer without signing it. The client signs "payload"
+// (ECDSA P-256 over its SHA-256, ASN.1 DER, hex) with its own key and
+// posts the tx with the signature to /api/tx/raw.
+func GetUnsignedTransaction(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+
+	var req CreateTxRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	if !walletExists(ctx, req.ReceiverWallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid receiver wallet"})
+		return
+	}
 
-	// load sender keys from DB
-	userCol := db.Col("users")
 	var user models.User
-	if err := userCol.FindOne(ctx, bson.M{"wallet_id": walletID}).Decode(&user); err != nil {
+	if err := db.Col("users").FindOne(ctx, bson.M{"wallet_id": walletID}).Decode(&user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
 		return
 	}
 
-	privHex, err := appCrypto.DecryptPrivateKey(user.EncryptedPrivKey)
+	transfer, err := req.transfer()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("decrypt key failed: %v", err)})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	privKey, pubKey, err := appCrypto.PrivateFromHex(privHex)
+	tx, selected, err := txbuilder.Build(ctx, user, transfer)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("invalid private key: %v", err)})
+		buildFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tx":             tx,
+		"selected_utxos": selected,
+		"payload":        blockchain.SigningPayload(*tx),
+	})
+}
+
+// coinSelectionResult is what one strategy would do for a dry run.
+type coinSelectionResult struct {
+	Strategy      utxo.Strategy         `json:"strategy"`
+	SelectedUTXOs []models.UTXO         `json:"selected_utxos,omitempty"`
+	InputTotal    models.Amount         `json:"input_total"`
+	Fee           models.Amount         `json:"fee"`
+	Change        models.Amount         `json:"change"`
+	Outputs       []models.TxUTXOOutput `json:"outputs,omitempty"`
+	Size          int                   `json:"size,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+// GET /api/tx/dry-run?receiver_wallet=...&amount=...&fee=...&strategy=...
+// Builds the transfer with every coin selection strategy (or only the one
+// given) and shows the coins, fee and change each would produce.
+// Nothing is signed or stored.
+func DryRunTransaction(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+
+	var req CreateTxRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	sig, err := appCrypto.SignMessage(privKey, payload)
+	transfer, err := req.transfer()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "sign failed"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// verify (for safety)
-	ok, _ := appCrypto.VerifySignature(*pubKey, payload, sig)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "signature invalid"})
+	ctx := context.Background()
+	if !walletExists(ctx, req.ReceiverWallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid receiver wallet"})
+		return
+	}
+
+	var user models.User
+	if err := db.Col("users").FindOne(ctx, bson.M{"wallet_id": walletID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
 		return
 	}
 
-	inputs := []models.TxUTXOInput{}
-	for _, u := range selected {
-		inputs = append(inputs, models.TxUTXOInput{UTXOId: u.ID, Index: u.Index})
+	strategies := utxo.Strategies()
+	if req.Strategy != "" {
+		strategies = []utxo.Strategy{transfer.Strategy}
+	}
+
+	results := make([]coinSelectionResult, 0, len(strategies))
+	for _, s := range strategies {
+		transfer.Strategy = s
+		res := coinSelectionResult{Strategy: s}
+		tx, selected, err := txbuilder.Build(ctx, user, transfer)
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		res.SelectedUTXOs = selected
+		for _, u := range selected {
+			res.InputTotal += u.Amount
+		}
+		res.Fee = tx.Fee
+		res.Change = res.InputTotal - tx.Amount - tx.Fee
+		res.Outputs = tx.Outputs
+		res.Size = blockchain.TxSize(*tx)
+		results = append(results, res)
 	}
 
-	outputs := []models.TxUTXOOutput{
-		{OwnerWallet: req.ReceiverWallet, Amount: req.Amount},
+	c.JSON(http.StatusOK, gin.H{
+		"amount":  req.Amount,
+		"results": results,
+	})
+}
+
+// POST /api/tx/raw
+// Accepts a transaction signed by the user's own key (see GET /api/tx/unsigned)
+// and submits it to the pending pool. The server never touches the private key.
+func SubmitRawTransaction(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+
+	var tx models.Transaction
+	if err := c.ShouldBindJSON(&tx); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if change > 0 {
-		outputs = append(outputs, models.TxUTXOOutput{OwnerWallet: walletID, Amount: change})
+
+	ctx := context.Background()
+	var user models.User
+	if err := db.Col("users").FindOne(ctx, bson.M{"wallet_id": walletID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		return
 	}
 
-	tx := models.Transaction{
-		SenderWallet:   walletID,
-		ReceiverWallet: req.ReceiverWallet,
-		Amount:         req.Amount,
-		Note:           req.Note,
-		Timestamp:      timestamp,
-		SenderPubKey:   user.PublicKey,
-		Signature:      sig,
-		Inputs:         inputs,
-		Outputs:        outputs,
-		Type:           "normal",
-		Status:         "pending",
+	if tx.SenderWallet != walletID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "sender wallet does not match logged-in wallet"})
+		return
+	}
+	if tx.Type != "normal" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only normal transfers can be submitted raw"})
+		return
+	}
+	if !walletExists(ctx, tx.ReceiverWallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid receiver wallet"})
+		return
+	}
+
+	// the signature must come from the key registered for this wallet
+	pub, err := appCrypto.PublicFromHex(user.PublicKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid stored public key"})
+		return
+	}
+	if tx.SenderPubKey != user.PublicKey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sender public key does not match wallet"})
+		return
+	}
+	if ok, err := appCrypto.VerifySignature(*pub, blockchain.SigningPayload(tx), tx.Signature); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("signature invalid: %v", err)})
+		return
+	}
+	payments := make([]txbuilder.Payment, len(tx.Outputs))
+	for i, o := range tx.Outputs {
+		payments[i] = txbuilder.Payment{Receiver: o.OwnerWallet, Amount: o.Amount}
+	}
+	if err := checkOutgoing(ctx, user, payments); err != nil {
+		switch {
+		case errors.Is(err, blockchain.ErrInvalidTx):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrBeneficiaryOnly):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		}
+		return
+	}
+
+	if err := txbuilder.Submit(ctx, &tx); err != nil {
+		if errors.Is(err, blockchain.ErrInvalidTx) || errors.Is(err, mempool.ErrConflict) || errors.Is(err, mempool.ErrDuplicate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
 	}
 
-	txCol := db.Col("pending_transactions")
-	_, err = txCol.InsertOne(ctx, tx)
+	c.JSON(http.StatusOK, gin.H{"message": "signed transaction accepted (pending mining)", "tx_id": tx.ID})
+}
+
+// GET /api/tx/:id/proof
+// Returns the merkle branch proving a confirmed tx is committed to by its
+// block header, so it can be checked with blockchain.VerifyMerkleProof
+// without downloading the block.
+func GetTxProof(c *gin.Context) {
+	txID := c.Param("id")
+	ctx := context.Background()
+
+	var block models.Block
+	err := db.Col("blocks").FindOne(ctx, bson.M{"transactions._id": txID}).Decode(&block)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tx not found in any block"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "transaction created (pending mining)"})
+	pos := -1
+	for i, t := range block.Transactions {
+		if t.ID == txID {
+			pos = i
+			break
+		}
+	}
+
+	leaf, branch, err := blockchain.BlockMerkleProof(block, pos)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tx_id":         txID,
+		"leaf_hash":     leaf,
+		"position":      pos,
+		"branch":        branch,
+		"block_index":   block.Index,
+		"block_hash":    block.Hash,
+		"block_version": block.Version,
+		"merkle_root":   block.MerkleRoot,
+		"verified":      blockchain.VerifyMerkleProof(leaf, branch, block.MerkleRoot),
+	})
 }
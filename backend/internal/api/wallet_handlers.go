@@ -2,13 +2,20 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
 	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func GetWalletProfile(c *gin.Context) {
@@ -22,11 +29,20 @@ func GetWalletProfile(c *gin.Context) {
 		return
 	}
 
-	balance, err := utxo.GetBalance(ctx, walletID)
+	addrs, err := userAddresses(ctx, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "balance error"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
 	}
+	var balances utxo.Balances
+	for _, a := range addrs {
+		b, err := utxo.GetBalances(ctx, a.WalletID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "balance error"})
+			return
+		}
+		balances.Add(b)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"full_name":      user.FullName,
@@ -35,28 +51,63 @@ func GetWalletProfile(c *gin.Context) {
 		"wallet_id":      user.WalletID,
 		"beneficiaries":  user.Beneficiaries,
 		"zakat_deducted": user.ZakatDeducted,
-		"balance":        balance,
+		"balance":        balances.Confirmed,
+		"balances":       balances,
 	})
 }
 
+// GET /api/wallet/balance
+// Totals the caller's addresses, with each address's share under
+// "addresses"; ?address= (wallet id or label) narrows it to one address.
 func GetBalance(c *gin.Context) {
-	walletID := c.GetString("wallet_id")
 	ctx := context.Background()
 
-	balance, err := utxo.GetBalance(ctx, walletID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "balance error"})
+	_, addrs, ok := selectAddresses(ctx, c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"balance": balance})
+	type addressBalance struct {
+		WalletID string `json:"wallet_id"`
+		Label    string `json:"label"`
+		utxo.Balances
+	}
+	var total utxo.Balances
+	breakdown := make([]addressBalance, 0, len(addrs))
+	for _, a := range addrs {
+		b, err := utxo.GetBalances(ctx, a.WalletID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "balance error"})
+			return
+		}
+		total.Add(b)
+		breakdown = append(breakdown, addressBalance{WalletID: a.WalletID, Label: a.Label, Balances: b})
+	}
+	// balance is the confirmed amount; spendable excludes outputs held by
+	// pending txs, immature ones and HTLCs
+	c.JSON(http.StatusOK, gin.H{
+		"balance":   total.Confirmed,
+		"confirmed": total.Confirmed,
+		"reserved":  total.Reserved,
+		"immature":  total.Immature,
+		"locked":    total.Locked,
+		"spendable": total.Spendable,
+		"addresses": breakdown,
+	})
 }
 
+// GET /api/wallet/utxos
+// Lists unspent outputs of all the caller's addresses, or of the one named
+// by ?address=; each output's owner_wallet tells which address holds it.
 func GetUTXOs(c *gin.Context) {
-	walletID := c.GetString("wallet_id")
 	ctx := context.Background()
 
+	_, addrs, ok := selectAddresses(ctx, c)
+	if !ok {
+		return
+	}
+
 	col := db.Col("utxos")
-	cur, err := col.Find(ctx, bson.M{"owner_wallet": walletID, "is_spent": false})
+	cur, err := col.Find(ctx, bson.M{"owner_wallet": bson.M{"$in": walletIDs(addrs)}, "is_spent": false})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
@@ -71,13 +122,106 @@ func GetUTXOs(c *gin.Context) {
 		}
 		out = append(out, u)
 	}
-	c.JSON(http.StatusOK, gin.H{"utxos": out})
+	c.JSON(http.StatusOK, gin.H{"utxos": out, "labels": addressLabels(addrs)})
+}
+
+// addressLabels maps the wallet ids of addrs to their labels.
+func addressLabels(addrs []models.Address) map[string]string {
+	labels := make(map[string]string, len(addrs))
+	for _, a := range addrs {
+		labels[a.WalletID] = a.Label
+	}
+	return labels
 }
 
 type BeneficiariesReq struct {
-	Beneficiaries []string `json:"beneficiaries"`
+	Beneficiaries []models.Beneficiary `json:"beneficiaries"`
+	// Password re-confirms the account when the list adds a wallet
+	Password string `json:"password"`
+}
+
+// passwordConfirmation is the re-entered account password accepted by
+// requests that weaken the beneficiaries-only setting.
+type passwordConfirmation struct {
+	Password string `json:"password"`
+}
+
+// confirmPassword checks the account password re-entered for a change that
+// weakens the beneficiaries-only setting, which exists to limit what a
+// stolen session token can do; the token alone must not be able to switch
+// it off or trust a new wallet. On failure it writes the error response
+// and returns false.
+func confirmPassword(c *gin.Context, user models.User, password, action string) bool {
+	if password == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "password confirmation required to " + action})
+		return false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		logger.AddSystemLog(c, "password_confirm_failed", fmt.Sprintf("wallet=%s action=%q", user.WalletID, action))
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid password"})
+		return false
+	}
+	return true
+}
+
+// loadUser fetches the user owning walletID.
+func loadUser(ctx context.Context, walletID string) (models.User, error) {
+	var user models.User
+	err := db.Col("users").FindOne(ctx, bson.M{"wallet_id": walletID}).Decode(&user)
+	return user, err
 }
 
+// checkBeneficiary validates a contact of owner and fills in defaults:
+// the nickname falls back to the wallet id.
+func checkBeneficiary(ctx context.Context, owner models.User, b *models.Beneficiary) error {
+	b.Name = strings.TrimSpace(b.Name)
+	b.WalletID = strings.TrimSpace(b.WalletID)
+	if b.WalletID == "" {
+		return errors.New("beneficiary wallet_id is required")
+	}
+	if b.WalletID == owner.WalletID {
+		return errors.New("cannot add your own wallet as a beneficiary")
+	}
+	if !walletExists(ctx, b.WalletID) {
+		return fmt.Errorf("beneficiary wallet %s does not exist", b.WalletID)
+	}
+	if b.Name == "" {
+		b.Name = b.WalletID
+	}
+	if b.DefaultAmount < 0 {
+		return fmt.Errorf("beneficiary %s: default amount must not be negative", b.Name)
+	}
+	if b.AddedAt.IsZero() {
+		b.AddedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func saveBeneficiaries(ctx context.Context, walletID string, list []models.Beneficiary) error {
+	_, err := db.Col("users").UpdateOne(ctx,
+		bson.M{"wallet_id": walletID},
+		bson.M{"$set": bson.M{"beneficiaries": list}},
+	)
+	return err
+}
+
+// GET /api/wallet/beneficiaries
+func GetBeneficiaries(c *gin.Context) {
+	user, err := loadUser(context.Background(), c.GetString("wallet_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"beneficiaries":          user.Beneficiaries,
+		"beneficiary_only":       user.BeneficiaryOnly,
+		"beneficiary_only_above": user.BeneficiaryOnlyAbove,
+	})
+}
+
+// POST /api/wallet/beneficiaries
+// Replaces the whole contact list. Entries may be contact objects or bare
+// wallet ids; every wallet must exist and nicknames must be unique.
 func UpdateBeneficiaries(c *gin.Context) {
 	walletID := c.GetString("wallet_id")
 	var req BeneficiariesReq
@@ -87,13 +231,170 @@ func UpdateBeneficiaries(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	_, err := db.Col("users").UpdateOne(ctx,
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	list := make([]models.Beneficiary, 0, len(req.Beneficiaries))
+	seen := map[string]bool{}
+	adds := false
+	for _, b := range req.Beneficiaries {
+		if err := checkBeneficiary(ctx, user, &b); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		adds = adds || !user.IsBeneficiary(b.WalletID)
+		key := strings.ToLower(b.Name)
+		if seen[key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("duplicate beneficiary name %q", b.Name)})
+			return
+		}
+		seen[key] = true
+		// keep when a contact was first added
+		if old, ok := user.FindBeneficiary(b.Name); ok && old.WalletID == b.WalletID && !old.AddedAt.IsZero() {
+			b.AddedAt = old.AddedAt
+		}
+		list = append(list, b)
+	}
+	if adds && !confirmPassword(c, user, req.Password, "add beneficiaries") {
+		return
+	}
+
+	if err := saveBeneficiaries(ctx, walletID, list); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "beneficiaries updated", "beneficiaries": list})
+}
+
+// PUT /api/wallet/beneficiaries/:name
+// Adds a contact under :name or updates the existing one. Trusting a new
+// wallet needs the account password in the body as well.
+func UpsertBeneficiary(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	var b models.Beneficiary
+	var confirm passwordConfirmation
+	if err := c.ShouldBindBodyWith(&b, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.ShouldBindBodyWith(&confirm, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	b.Name = c.Param("name")
+
+	ctx := context.Background()
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err := checkBeneficiary(ctx, user, &b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !user.IsBeneficiary(b.WalletID) && !confirmPassword(c, user, confirm.Password, "add a beneficiary") {
+		return
+	}
+
+	list := user.Beneficiaries
+	replaced := false
+	for i, old := range list {
+		if strings.EqualFold(old.Name, b.Name) {
+			if old.WalletID == b.WalletID && !old.AddedAt.IsZero() {
+				b.AddedAt = old.AddedAt
+			}
+			list[i] = b
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		list = append(list, b)
+	}
+
+	if err := saveBeneficiaries(ctx, walletID, list); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "beneficiary saved", "beneficiary": b})
+}
+
+// DELETE /api/wallet/beneficiaries/:name
+func DeleteBeneficiary(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	ctx := context.Background()
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	name := c.Param("name")
+	list := make([]models.Beneficiary, 0, len(user.Beneficiaries))
+	for _, b := range user.Beneficiaries {
+		if !strings.EqualFold(b.Name, name) {
+			list = append(list, b)
+		}
+	}
+	if len(list) == len(user.Beneficiaries) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "beneficiary not found"})
+		return
+	}
+
+	if err := saveBeneficiaries(ctx, walletID, list); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "beneficiary removed"})
+}
+
+type TransferPolicyReq struct {
+	BeneficiaryOnly      bool          `json:"beneficiary_only"`
+	BeneficiaryOnlyAbove models.Amount `json:"beneficiary_only_above" binding:"gte=0"`
+	// Password re-confirms the account when the change relaxes the policy
+	Password string `json:"password"`
+}
+
+// PUT /api/wallet/transfer-policy
+// Anti-fraud setting: when beneficiary_only is on, outgoing payments above
+// beneficiary_only_above may only go to saved beneficiaries. Turning it
+// off or raising the threshold needs the account password.
+func UpdateTransferPolicy(c *gin.Context) {
+	walletID := c.GetString("wallet_id")
+	var req TransferPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	user, err := loadUser(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	relaxes := user.BeneficiaryOnly && (!req.BeneficiaryOnly || req.BeneficiaryOnlyAbove > user.BeneficiaryOnlyAbove)
+	if relaxes && !confirmPassword(c, user, req.Password, "relax the transfer policy") {
+		return
+	}
+
+	_, err = db.Col("users").UpdateOne(ctx,
 		bson.M{"wallet_id": walletID},
-		bson.M{"$set": bson.M{"beneficiaries": req.Beneficiaries}},
+		bson.M{"$set": bson.M{
+			"beneficiary_only":       req.BeneficiaryOnly,
+			"beneficiary_only_above": req.BeneficiaryOnlyAbove,
+			"updated_at":             time.Now().UTC(),
+		}},
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "beneficiaries updated"})
+	logger.AddSystemLog(c, "transfer_policy_updated",
+		fmt.Sprintf("wallet=%s beneficiary_only=%t above=%s", walletID, req.BeneficiaryOnly, req.BeneficiaryOnlyAbove))
+	c.JSON(http.StatusOK, gin.H{"message": "transfer policy updated"})
 }
@@ -0,0 +1,207 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrStaleTip is returned when another block was appended to the chain
+// after the candidate block was built on top of the old tip.
+var ErrStaleTip = errors.New("chain tip moved while mining")
+
+// ApplyBlock appends a mined block and applies all of its effects: block
+// insert, input UTXOs spent, output UTXOs created, transactions confirmed
+// and the included txs removed from the pending pool (spending an input
+// also clears its reservation). b.ID is set on success.
+//
+// It runs as two Mongo transactions. The first checks the tip and stores
+// b as models.BlockApplying, the second writes its effects and marks it
+// applied; no block can be added on top while the tip is still applying.
+// If the effects fail the block is removed again, and if the process dies
+// between the two RecoverTip finishes or removes it on the next start.
+func ApplyBlock(ctx context.Context, b *models.Block) error {
+	if err := CheckCoinbase(*b); err != nil {
+		return err
+	}
+	err := db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		blocksCol := db.Col("blocks")
+
+		var tip models.Block
+		err := blocksCol.FindOne(sc, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&tip)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			if b.Index != 0 {
+				return ErrStaleTip
+			}
+		case err != nil:
+			return err
+		case tip.Index+1 != b.Index || tip.Hash != b.PreviousHash:
+			return ErrStaleTip
+		case tip.ApplyState == models.BlockApplying:
+			return fmt.Errorf("%w: block #%d is still being applied", ErrStaleTip, tip.Index)
+		}
+
+		b.ApplyState = models.BlockApplying
+		res, err := blocksCol.InsertOne(sc, b)
+		if err != nil {
+			return fmt.Errorf("insert block: %w", err)
+		}
+		if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+			b.ID = oid.Hex()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := finishApply(ctx, *b); err != nil {
+		if derr := discardBlock(ctx, *b); derr != nil {
+			log.Printf("block #%d: remove after failed apply: %v", b.Index, derr)
+		}
+		return err
+	}
+	b.ApplyState = models.BlockApplied
+	return nil
+}
+
+// finishApply writes the effects of a block stored as applying and marks
+// it applied, all in one transaction.
+func finishApply(ctx context.Context, b models.Block) error {
+	return db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if err := applyBlockEffects(sc, b); err != nil {
+			return err
+		}
+		res, err := db.Col("blocks").UpdateOne(sc,
+			blockApplying(b.ID),
+			bson.M{"$set": bson.M{"apply_state": models.BlockApplied}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return fmt.Errorf("block #%d is no longer waiting to be applied", b.Index)
+		}
+		return nil
+	})
+}
+
+// discardBlock removes a block stored as applying whose effects were never
+// written. Its txs are still pending and can be mined again.
+func discardBlock(ctx context.Context, b models.Block) error {
+	_, err := db.Col("blocks").DeleteOne(ctx, blockApplying(b.ID))
+	return err
+}
+
+func blockApplying(id string) bson.M {
+	filter := db.ByID(id)
+	filter["apply_state"] = models.BlockApplying
+	return filter
+}
+
+// applyBlockEffects writes the UTXO and transaction changes of b.
+// Every step is idempotent so it can also roll a partially applied block
+// forward during recovery.
+func applyBlockEffects(ctx context.Context, b models.Block) error {
+	utxoCol := db.Col("utxos")
+	txCol := db.Col("transactions")
+	pendingCol := db.Col("pending_transactions")
+
+	for _, t := range b.Transactions {
+		// spend inputs; an input already spent by a different tx aborts the block
+		for _, in := range t.Inputs {
+			filter := db.ByID(in.UTXOId)
+			filter["$or"] = bson.A{
+				bson.M{"is_spent": false},
+				bson.M{"spent_in_tx_id": t.ID},
+			}
+			res, err := utxoCol.UpdateOne(ctx, filter, bson.M{
+				"$set": bson.M{
+					"is_spent":       true,
+					"spent_in_tx_id": t.ID,
+				},
+				"$unset": bson.M{"reserved_by": "", "reserved_until": ""},
+			})
+			if err != nil {
+				return err
+			}
+			if res.MatchedCount == 0 {
+				return fmt.Errorf("tx %s: input utxo %s missing or already spent", t.ID, in.UTXOId)
+			}
+		}
+
+		// create outputs, keyed by outpoint so inputs reference chain data, not Mongo ids
+		for i, o := range t.Outputs {
+			_, err := utxoCol.UpdateOne(ctx,
+				bson.M{"tx_id": t.ID, "index": i},
+				bson.M{"$setOnInsert": models.UTXO{
+					ID:             models.OutpointID(t.ID, i),
+					TxID:           t.ID,
+					Index:          i,
+					OwnerWallet:    o.OwnerWallet,
+					Amount:         o.Amount,
+					IsSpent:        false,
+					MaturityHeight: o.MaturityHeight,
+					Lock:           o.Lock,
+				}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// confirm tx and drop it from the pending pool
+		t.Status = "confirmed"
+		t.BlockID = b.ID
+		if _, err := txCol.ReplaceOne(ctx, bson.M{"_id": t.ID}, t, options.Replace().SetUpsert(true)); err != nil {
+			return err
+		}
+		if !IsCoinbase(t) {
+			if _, err := pendingCol.DeleteOne(ctx, db.ByID(t.ID)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecoverTip finishes a block ApplyBlock stored but was interrupted
+// before applying, or removes it if its effects can no longer be applied.
+// Only the tip can be in that state, and only blocks carrying the applying
+// marker are touched: older blocks have no marker, and their tx records
+// can't tell an interrupted apply from txs the miner of the time left out,
+// so replaying them would spend the wrong outputs.
+func RecoverTip(ctx context.Context) error {
+	var tip models.Block
+	err := db.Col("blocks").FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&tip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if tip.ApplyState != models.BlockApplying {
+		return nil
+	}
+
+	log.Printf("block #%d was not applied, rolling forward", tip.Index)
+	err = finishApply(ctx, tip)
+	if err == nil {
+		return nil
+	}
+	log.Printf("block #%d: %v; removing it", tip.Index, err)
+	if derr := discardBlock(ctx, tip); derr != nil {
+		return fmt.Errorf("recover block #%d: %w", tip.Index, errors.Join(err, derr))
+	}
+	return nil
+}
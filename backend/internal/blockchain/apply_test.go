@@ -0,0 +1,132 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// useTestDB points db at a fresh database on the replica set named by
+// MONGO_TEST_URI (transactions need one) and drops it afterwards.
+func useTestDB(t *testing.T) context.Context {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+	config.AppConfig = &config.Config{
+		MongoURI: uri,
+		DBName:   fmt.Sprintf("wallet_test_%d", time.Now().UnixNano()),
+	}
+	if err := db.ConnectMongo(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	t.Cleanup(func() {
+		_ = db.DB.Drop(ctx)
+		_ = db.Client.Disconnect(ctx)
+	})
+	return ctx
+}
+
+func testCoinbase(height int, miner string) models.Transaction {
+	tx := models.Transaction{
+		SenderWallet:   "SYSTEM_COINBASE",
+		ReceiverWallet: miner,
+		Amount:         models.Coin,
+		Note:           fmt.Sprintf("Mining reward for block #%d", height),
+		Outputs:        []models.TxUTXOOutput{{OwnerWallet: miner, Amount: models.Coin}},
+		Type:           "mining_reward",
+		Status:         "confirmed",
+		Timestamp:      time.Now().UTC().Truncate(time.Millisecond),
+	}
+	SealTx(&tx)
+	return tx
+}
+
+// A block stored as applying whose effects never committed is rolled
+// forward on startup.
+func TestRecoverTipRollsForward(t *testing.T) {
+	ctx := useTestDB(t)
+
+	cb := testCoinbase(0, "miner")
+	b := models.Block{Index: 0, Transactions: []models.Transaction{cb}, Hash: "h0", ApplyState: models.BlockApplying}
+	res, err := db.Col("blocks").InsertOne(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RecoverTip(ctx); err != nil {
+		t.Fatalf("RecoverTip: %v", err)
+	}
+
+	var got models.Block
+	if err := db.Col("blocks").FindOne(ctx, bson.M{"_id": res.InsertedID}).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ApplyState != models.BlockApplied {
+		t.Errorf("apply state = %q, want %q", got.ApplyState, models.BlockApplied)
+	}
+	var u models.UTXO
+	if err := db.Col("utxos").FindOne(ctx, bson.M{"tx_id": cb.ID, "index": 0}).Decode(&u); err != nil {
+		t.Fatalf("coinbase output not created: %v", err)
+	}
+	if u.IsSpent || u.Amount != models.Coin {
+		t.Errorf("coinbase output = %+v", u)
+	}
+	var tx models.Transaction
+	if err := db.Col("transactions").FindOne(ctx, bson.M{"_id": cb.ID}).Decode(&tx); err != nil || tx.Status != "confirmed" {
+		t.Errorf("coinbase tx = %+v, %v, want confirmed", tx, err)
+	}
+
+	// a second start finds nothing to do
+	if err := RecoverTip(ctx); err != nil {
+		t.Fatalf("RecoverTip again: %v", err)
+	}
+}
+
+// A block stored as applying whose effects can't be written is removed,
+// leaving the previous block as the tip.
+func TestRecoverTipDiscardsUnappliable(t *testing.T) {
+	ctx := useTestDB(t)
+
+	genesis := models.Block{Index: 0, Transactions: []models.Transaction{testCoinbase(0, "miner")}, Hash: "h0", ApplyState: models.BlockApplied}
+	spend := models.Transaction{
+		SenderWallet: "miner",
+		Inputs:       []models.TxUTXOInput{{UTXOId: "missing:0"}},
+		Outputs:      []models.TxUTXOOutput{{OwnerWallet: "bob", Amount: 1}},
+		Type:         "normal",
+		Timestamp:    time.Now().UTC().Truncate(time.Millisecond),
+	}
+	SealTx(&spend)
+	next := models.Block{
+		Index:        1,
+		Transactions: []models.Transaction{testCoinbase(1, "miner"), spend},
+		PreviousHash: "h0",
+		Hash:         "h1",
+		ApplyState:   models.BlockApplying,
+	}
+	for _, b := range []models.Block{genesis, next} {
+		if _, err := db.Col("blocks").InsertOne(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RecoverTip(ctx); err != nil {
+		t.Fatalf("RecoverTip: %v", err)
+	}
+
+	if n, err := db.Col("blocks").CountDocuments(ctx, bson.M{"index": 1}); err != nil || n != 0 {
+		t.Errorf("unappliable block still stored: count %d, %v", n, err)
+	}
+	if n, err := db.Col("utxos").CountDocuments(ctx, bson.M{}); err != nil || n != 0 {
+		t.Errorf("discarded block left %d utxos, %v", n, err)
+	}
+}
@@ -1,3 +1,232 @@
 package blockchain
 
-// Placeholder for future chain-related helpers (like full chain validation, re-sync, etc.)
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChainReport is the outcome of a full chain verification.
+type ChainReport struct {
+	Valid          bool   `json:"valid"`
+	BlocksChecked  int    `json:"blocks_checked"`
+	FirstBadHeight *int   `json:"first_bad_height,omitempty"`
+	BadBlockHash   string `json:"bad_block_hash,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// chainVerifier carries the state accumulated while replaying the chain.
+type chainVerifier struct {
+	confirmedTxs map[string]bool // tx ids included in blocks seen so far
+	spentUTXOs   map[string]int  // utxo id -> height of the block spending it
+}
+
+// VerifyChain walks every block in the blocks collection from genesis and
+// re-checks headers, proof-of-work, merkle roots, signatures and UTXO spends.
+// It stops at the first bad block and reports its height and the reason.
+func VerifyChain(ctx context.Context) (*ChainReport, error) {
+	cur, err := db.Col("blocks").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"index": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	v := &chainVerifier{
+		confirmedTxs: map[string]bool{},
+		spentUTXOs:   map[string]int{},
+	}
+	report := &ChainReport{Valid: true}
+
+	// headers of every verified block, for retarget window lookups
+	var headers []models.Block
+	var prev *models.Block
+	for cur.Next(ctx) {
+		height := 0
+		if prev != nil {
+			height = prev.Index + 1
+		}
+
+		var b models.Block
+		if err := cur.Decode(&b); err != nil {
+			report.fail(height, "", fmt.Sprintf("cannot decode block: %v", err))
+			return report, nil
+		}
+
+		var windowStart *models.Block
+		if start := b.Index - config.AppConfig.RetargetInterval; start >= 0 && start < len(headers) {
+			windowStart = &headers[start]
+		}
+		if err := ValidateBlock(b, prev, ExpectedBits(b.Index, prev, windowStart)); err != nil {
+			report.fail(b.Index, b.Hash, err.Error())
+			return report, nil
+		}
+		if err := v.verifyTransactions(ctx, b); err != nil {
+			report.fail(b.Index, b.Hash, err.Error())
+			return report, nil
+		}
+
+		report.BlocksChecked++
+		header := b
+		header.Transactions = nil
+		headers = append(headers, header)
+		prev = &headers[len(headers)-1]
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *ChainReport) fail(height int, hash, reason string) {
+	r.Valid = false
+	r.FirstBadHeight = &height
+	r.BadBlockHash = hash
+	r.Reason = reason
+}
+
+// verifyTransactions replays every spend in b and checks the coinbase
+// pays no more than the subsidy plus the fees of b's transactions.
+func (v *chainVerifier) verifyTransactions(ctx context.Context, b models.Block) error {
+	if len(b.Transactions) == 0 || !IsCoinbase(b.Transactions[0]) {
+		return errors.New("first transaction is not a coinbase")
+	}
+
+	inBlock := map[string]bool{}
+	var fees models.Amount
+	for i, tx := range b.Transactions {
+		if tx.ID == "" {
+			return fmt.Errorf("tx #%d has no id", i)
+		}
+		if v.confirmedTxs[tx.ID] || inBlock[tx.ID] {
+			return fmt.Errorf("tx %s included twice", tx.ID)
+		}
+		inBlock[tx.ID] = true
+		if err := VerifyTxID(tx); err != nil {
+			return err
+		}
+
+		if i == 0 {
+			continue
+		}
+		if IsCoinbase(tx) {
+			return fmt.Errorf("tx %s: extra coinbase", tx.ID)
+		}
+		fee, err := v.verifySpend(ctx, b.Index, tx)
+		if err != nil {
+			return fmt.Errorf("tx %s: %w", tx.ID, err)
+		}
+		if fees, err = models.AddAmounts(fees, fee, MaxMoney()); err != nil {
+			return fmt.Errorf("tx %s: fees: %w", tx.ID, err)
+		}
+	}
+
+	coinbase := b.Transactions[0]
+	paid, err := coinbaseValue(coinbase)
+	if err != nil {
+		return fmt.Errorf("coinbase %s: %w", coinbase.ID, err)
+	}
+	if allowed := Subsidy(b.Index) + fees; paid > allowed {
+		return fmt.Errorf("coinbase %s pays %s, above subsidy plus fees %s", coinbase.ID, paid, allowed)
+	}
+
+	for id := range inBlock {
+		v.confirmedTxs[id] = true
+	}
+	return nil
+}
+
+// checkConfirmedInput checks that tx, confirmed at height, may spend u and
+// that the UTXO set records u as spent by tx. Docs spent before spenders
+// were recorded have no SpentInTxID and are accepted.
+func checkConfirmedInput(tx models.Transaction, u models.UTXO, height int) error {
+	from, err := spendHeight(tx, u, height)
+	if err != nil {
+		return err
+	}
+	if !u.IsSpent {
+		return fmt.Errorf("input utxo %s is spent on chain but unspent in utxo set", u.ID)
+	}
+	if u.SpentInTxID != "" && u.SpentInTxID != tx.ID {
+		return fmt.Errorf("input utxo %s was spent by %s", u.ID, u.SpentInTxID)
+	}
+	if from > height {
+		return fmt.Errorf("input utxo %s spent at height %d, before it may be from %d", u.ID, height, from)
+	}
+	return nil
+}
+
+// verifySpend checks a user transaction's signature and that each input is
+// an existing, previously confirmed and mature output the sender may spend
+// (see spendHeight), spent only once.
+// It returns the fee the tx pays.
+func (v *chainVerifier) verifySpend(ctx context.Context, height int, tx models.Transaction) (models.Amount, error) {
+	if err := VerifyTxSignature(tx); err != nil {
+		return 0, err
+	}
+	if len(tx.Inputs) == 0 {
+		return 0, errors.New("no inputs")
+	}
+
+	var inTotal models.Amount
+	for _, in := range tx.Inputs {
+		if h, ok := v.spentUTXOs[in.UTXOId]; ok {
+			return 0, fmt.Errorf("utxo %s already spent at height %d", in.UTXOId, h)
+		}
+
+		var u models.UTXO
+		if err := db.Col("utxos").FindOne(ctx, db.ByID(in.UTXOId)).Decode(&u); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return 0, fmt.Errorf("input utxo %s not found", in.UTXOId)
+			}
+			return 0, err
+		}
+		if err := checkConfirmedInput(tx, u, height); err != nil {
+			return 0, err
+		}
+		if !v.confirmedTxs[u.TxID] {
+			faucet, err := isFaucetTx(ctx, u.TxID)
+			if err != nil {
+				return 0, err
+			}
+			if !faucet {
+				return 0, fmt.Errorf("input utxo %s comes from unconfirmed tx %s", in.UTXOId, u.TxID)
+			}
+		}
+
+		v.spentUTXOs[in.UTXOId] = height
+		var err error
+		if inTotal, err = models.AddAmounts(inTotal, u.Amount, MaxMoney()); err != nil {
+			return 0, fmt.Errorf("inputs: %w", err)
+		}
+	}
+
+	out, err := OutputsTotal(tx)
+	if err != nil {
+		return 0, err
+	}
+	if out > inTotal {
+		return 0, fmt.Errorf("outputs %s exceed inputs %s", out, inTotal)
+	}
+	// txs from before explicit fees declare none
+	if tx.Fee != 0 && !feeMatches(tx, inTotal, out) {
+		return 0, fmt.Errorf("declared fee %s, inputs minus outputs is %s", tx.Fee, inTotal-out)
+	}
+	return inTotal - out, nil
+}
+
+// isFaucetTx reports whether id names an admin faucet funding, which
+// creates UTXOs outside of any block.
+func isFaucetTx(ctx context.Context, id string) (bool, error) {
+	err := db.Col("transactions").FindOne(ctx, bson.M{"_id": id, "type": "faucet"}).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
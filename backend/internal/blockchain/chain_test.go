@@ -0,0 +1,40 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// Two txs of a block listing the same output: only the one the UTXO set
+// records as its spender may have consumed it.
+func TestCheckConfirmedInputSpender(t *testing.T) {
+	u := models.UTXO{ID: "u1", OwnerWallet: "alice", Amount: 5 * models.Coin, IsSpent: true, SpentInTxID: "txA"}
+	txA := models.Transaction{ID: "txA", SenderWallet: "alice"}
+	txB := models.Transaction{ID: "txB", SenderWallet: "alice"}
+
+	if err := checkConfirmedInput(txA, u, 3); err != nil {
+		t.Errorf("spender txA: %v", err)
+	}
+	if err := checkConfirmedInput(txB, u, 3); err == nil {
+		t.Error("txB spending an output consumed by txA passed")
+	}
+
+	legacy := u
+	legacy.SpentInTxID = ""
+	if err := checkConfirmedInput(txB, legacy, 3); err != nil {
+		t.Errorf("legacy doc without spender: %v", err)
+	}
+
+	unspent := u
+	unspent.IsSpent = false
+	if err := checkConfirmedInput(txA, unspent, 3); err == nil {
+		t.Error("output unspent in the utxo set passed")
+	}
+
+	immature := u
+	immature.MaturityHeight = 4
+	if err := checkConfirmedInput(txA, immature, 3); err == nil {
+		t.Error("output spent before maturity passed")
+	}
+}
@@ -0,0 +1,144 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BlockVersionRetarget marks blocks that carry their own compact target in
+// Bits and commit to it in the header hash. Older blocks were checked
+// against the fixed POW_DIFFICULTY hex-zero prefix.
+const BlockVersionRetarget = 2
+
+// maxAdjustFactor bounds how far one retarget can move the target.
+const maxAdjustFactor = 4
+
+// powLimit is the easiest target allowed: one leading zero hex digit.
+var powLimit = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 252), big.NewInt(1))
+
+// CompactToBig expands Bitcoin-style compact bits (1-byte exponent,
+// 3-byte mantissa) into a 256-bit target.
+func CompactToBig(bits uint32) *big.Int {
+	mantissa := int64(bits & 0x007fffff)
+	exponent := uint(bits >> 24)
+	target := big.NewInt(mantissa)
+	if exponent <= 3 {
+		return target.Rsh(target, 8*(3-exponent))
+	}
+	return target.Lsh(target, 8*(exponent-3))
+}
+
+// BigToCompact encodes a target as compact bits, dropping precision below
+// the top three bytes.
+func BigToCompact(target *big.Int) uint32 {
+	if target.Sign() <= 0 {
+		return 0
+	}
+	exponent := uint((target.BitLen() + 7) / 8)
+	var mantissa uint32
+	if exponent <= 3 {
+		mantissa = uint32(target.Uint64() << (8 * (3 - exponent)))
+	} else {
+		mantissa = uint32(new(big.Int).Rsh(target, 8*(exponent-3)).Uint64())
+	}
+	// keep the mantissa's sign bit clear
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		exponent++
+	}
+	return uint32(exponent<<24) | mantissa
+}
+
+// legacyTarget is the threshold equivalent to config.PowDifficulty leading
+// hex zeros: 2^(256-4d) - 1.
+func legacyTarget() *big.Int {
+	shift := 256 - 4*config.AppConfig.PowDifficulty
+	if shift < 0 {
+		shift = 0
+	}
+	t := new(big.Int).Lsh(big.NewInt(1), uint(shift))
+	t.Sub(t, big.NewInt(1))
+	if t.Cmp(powLimit) > 0 {
+		return new(big.Int).Set(powLimit)
+	}
+	return t
+}
+
+// effectiveBits is the compact target a stored block was held to.
+func effectiveBits(b models.Block) uint32 {
+	if b.Version >= BlockVersionRetarget {
+		return b.Bits
+	}
+	return BigToCompact(legacyTarget())
+}
+
+// ExpectedBits returns the compact target the block at height must meet.
+// Every config.RetargetInterval blocks the target is scaled by how long the
+// last window actually took versus config.TargetBlockTime, clamped to a
+// factor of 4 either way. windowStart is the block at height-RetargetInterval
+// and is only needed on retarget heights.
+func ExpectedBits(height int, prev, windowStart *models.Block) uint32 {
+	if prev == nil {
+		return BigToCompact(legacyTarget())
+	}
+	prevBits := effectiveBits(*prev)
+
+	interval := config.AppConfig.RetargetInterval
+	if height%interval != 0 || windowStart == nil {
+		return prevBits
+	}
+	return retarget(prevBits, windowStart.Timestamp, prev.Timestamp)
+}
+
+// retarget scales a target by actual/expected time of the last window.
+// The window from its first to its last block spans interval-1 gaps.
+func retarget(prevBits uint32, first, last time.Time) uint32 {
+	expected := config.AppConfig.TargetBlockTime * time.Duration(config.AppConfig.RetargetInterval-1)
+	actual := last.Sub(first)
+	if actual < expected/maxAdjustFactor {
+		actual = expected / maxAdjustFactor
+	}
+	if actual > expected*maxAdjustFactor {
+		actual = expected * maxAdjustFactor
+	}
+
+	target := CompactToBig(prevBits)
+	target.Mul(target, big.NewInt(int64(actual)))
+	target.Div(target, big.NewInt(int64(expected)))
+	if target.Cmp(powLimit) > 0 {
+		target.Set(powLimit)
+	}
+	return BigToCompact(target)
+}
+
+// NextBits computes the target for the block after prev (nil for genesis),
+// loading the retarget window's first block from the database if needed.
+func NextBits(ctx context.Context, prev *models.Block) (uint32, error) {
+	if prev == nil {
+		return ExpectedBits(0, nil, nil), nil
+	}
+	height := prev.Index + 1
+	interval := config.AppConfig.RetargetInterval
+	if height%interval != 0 {
+		return ExpectedBits(height, prev, nil), nil
+	}
+
+	var start models.Block
+	if err := db.Col("blocks").FindOne(ctx, bson.M{"index": height - interval}).Decode(&start); err != nil {
+		return 0, errors.New("missing retarget window start block")
+	}
+	return ExpectedBits(height, prev, &start), nil
+}
+
+// meetsTarget reports whether a hex block hash is at or below target.
+func meetsTarget(hash string, target *big.Int) bool {
+	h, ok := new(big.Int).SetString(hash, 16)
+	return ok && h.Cmp(target) <= 0
+}
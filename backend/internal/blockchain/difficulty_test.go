@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCompactBits(t *testing.T) {
+	tests := []struct {
+		bits   uint32
+		target string // hex
+		want   uint32 // bits BigToCompact gives back, when not normalised
+	}{
+		{bits: 0x1d00ffff, target: "ffff0000000000000000000000000000000000000000000000000000"},
+		{bits: 0x1b0404cb, target: "404cb000000000000000000000000000000000000000000000000"},
+		{bits: 0x200fffff, target: "fffff0000000000000000000000000000000000000000000000000000000000"},
+		{bits: 0x04123456, target: "12345600"},
+		{bits: 0x03123456, target: "123456"},
+		{bits: 0x02008000, target: "80"},
+		{bits: 0x01123456, target: "12", want: 0x01120000},
+		{bits: 0x05009234, target: "92340000"},
+	}
+	for _, tt := range tests {
+		target := CompactToBig(tt.bits)
+		if got := target.Text(16); got != tt.target {
+			t.Errorf("CompactToBig(%#08x) = %s, want %s", tt.bits, got, tt.target)
+		}
+		want := tt.want
+		if want == 0 {
+			want = tt.bits
+		}
+		if got := BigToCompact(target); got != want {
+			t.Errorf("BigToCompact(%s) = %#08x, want %#08x", tt.target, got, want)
+		}
+	}
+
+	if got := BigToCompact(big.NewInt(0)); got != 0 {
+		t.Errorf("BigToCompact(0) = %#08x, want 0", got)
+	}
+	// the easiest target survives the round trip without getting easier
+	if got := CompactToBig(BigToCompact(powLimit)); got.Cmp(powLimit) > 0 {
+		t.Errorf("compact powLimit %s is above powLimit", got.Text(16))
+	}
+}
+
+func TestMeetsTarget(t *testing.T) {
+	target := CompactToBig(0x1f00ffff) // 0000ffff00...
+	tests := []struct {
+		hash string
+		want bool
+	}{
+		{"0000fffeffffffffffffffffffffffffffffffffffffffffffffffffffffffff", true},
+		{"0000ff0000000000000000000000000000000000000000000000000000000000", true},
+		{"0001000000000000000000000000000000000000000000000000000000000000", false},
+		{"not hex", false},
+	}
+	for _, tt := range tests {
+		if got := meetsTarget(tt.hash, target); got != tt.want {
+			t.Errorf("meetsTarget(%s) = %v, want %v", tt.hash, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// HashPreimage returns the hash lock a hex preimage opens: hex SHA-256 of
+// its bytes.
+func HashPreimage(preimageHex string) (string, error) {
+	b, err := hex.DecodeString(preimageHex)
+	if err != nil || len(b) == 0 {
+		return "", errors.New("preimage must be non-empty hex")
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// isHTLCSpend reports whether tx claims or refunds HTLC outputs.
+func isHTLCSpend(tx models.Transaction) bool {
+	return tx.Type == "htlc_claim" || tx.Type == "htlc_refund"
+}
+
+// checkOutputLocks validates the locks tx puts on its outputs.
+func checkOutputLocks(tx models.Transaction) error {
+	for i, o := range tx.Outputs {
+		l := o.Lock
+		if l == nil {
+			continue
+		}
+		if l.Type != models.LockHTLC {
+			return invalidTx("output %d: unknown lock type %q", i, l.Type)
+		}
+		if b, err := hex.DecodeString(l.HashLock); err != nil || len(b) != sha256.Size {
+			return invalidTx("output %d: hash lock must be a hex SHA-256", i)
+		}
+		if l.Recipient != o.OwnerWallet {
+			return invalidTx("output %d: HTLC recipient must own the output", i)
+		}
+		if l.Refund == "" {
+			return invalidTx("output %d: HTLC has no refund wallet", i)
+		}
+		if l.Deadline <= 0 {
+			return invalidTx("output %d: HTLC deadline must be a positive block height", i)
+		}
+	}
+	return nil
+}
+
+// spendHeight checks that tx may spend u in a block at height and returns
+// the first height it may do so from: u's maturity, or for an HTLC refund
+// also its deadline. An unlocked output needs its owner as sender; an HTLC
+// needs the recipient's htlc_claim with the preimage before the deadline,
+// or the refund wallet's htlc_refund.
+func spendHeight(tx models.Transaction, u models.UTXO, height int) (int, error) {
+	l := u.Lock
+	if l == nil {
+		if u.OwnerWallet != tx.SenderWallet {
+			return 0, fmt.Errorf("input utxo %s is not owned by sender", u.ID)
+		}
+		return u.MaturityHeight, nil
+	}
+
+	switch tx.Type {
+	case "htlc_claim":
+		if tx.SenderWallet != l.Recipient {
+			return 0, fmt.Errorf("only the recipient can claim HTLC %s", u.ID)
+		}
+		if hash, err := HashPreimage(tx.Preimage); err != nil || !strings.EqualFold(hash, l.HashLock) {
+			return 0, fmt.Errorf("preimage does not open HTLC %s", u.ID)
+		}
+		if height >= l.Deadline {
+			return 0, fmt.Errorf("HTLC %s expired at height %d", u.ID, l.Deadline)
+		}
+		return u.MaturityHeight, nil
+	case "htlc_refund":
+		if tx.SenderWallet != l.Refund {
+			return 0, fmt.Errorf("only the refund wallet can refund HTLC %s", u.ID)
+		}
+		return max(u.MaturityHeight, l.Deadline), nil
+	default:
+		return 0, fmt.Errorf("input utxo %s is an HTLC, spend it with htlc_claim or htlc_refund", u.ID)
+	}
+}
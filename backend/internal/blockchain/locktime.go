@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BlockVersionTimeLocks marks blocks whose coinbase outputs carry a
+// maturity height of at least config.CoinbaseMaturity blocks.
+const BlockVersionTimeLocks = 3
+
+// LockTimeThreshold splits lock_time values as in Bitcoin: below it a
+// lock time is a block height, from it on a unix timestamp in seconds.
+const LockTimeThreshold = 500000000
+
+// ErrNotFinal is returned for a tx that can't be mined yet because of its
+// lock time or an immature input but may be later. It does not wrap
+// ErrInvalidTx, so such txs stay in the mempool.
+var ErrNotFinal = errors.New("transaction not final")
+
+// IsFinal reports whether tx's lock time allows it into a block at height
+// with timestamp blockTime.
+func IsFinal(tx models.Transaction, height int, blockTime time.Time) bool {
+	if tx.LockTime < LockTimeThreshold {
+		return int64(height) >= tx.LockTime
+	}
+	return blockTime.Unix() >= tx.LockTime
+}
+
+// describeLock renders tx's lock time for error messages.
+func describeLock(tx models.Transaction) string {
+	if tx.LockTime < LockTimeThreshold {
+		return fmt.Sprintf("height %d", tx.LockTime)
+	}
+	return time.Unix(tx.LockTime, 0).UTC().Format(time.RFC3339)
+}
+
+// checkLocks rejects negative lock values and locks on legacy txs, whose
+// signing payload doesn't cover them.
+func checkLocks(tx models.Transaction) error {
+	locked := tx.LockTime != 0 || tx.Preimage != ""
+	for _, o := range tx.Outputs {
+		if o.MaturityHeight < 0 {
+			return invalidTx("negative output maturity height")
+		}
+		locked = locked || o.MaturityHeight != 0 || o.Lock != nil
+	}
+	if tx.LockTime < 0 {
+		return invalidTx("negative lock time")
+	}
+	if locked && tx.Version < TxVersionCanonical {
+		return invalidTx("lock time, maturity and output locks need the canonical encoding")
+	}
+	return nil
+}
+
+// checkCoinbaseMaturity checks that every output of a time-lock block's
+// coinbase matures no earlier than config.CoinbaseMaturity blocks on.
+func checkCoinbaseMaturity(b models.Block) error {
+	if b.Version < BlockVersionTimeLocks {
+		return nil
+	}
+	want := b.Index + config.AppConfig.CoinbaseMaturity
+	for i, o := range b.Transactions[0].Outputs {
+		if o.MaturityHeight < want {
+			return fmt.Errorf("coinbase output %d matures at height %d, want at least %d", i, o.MaturityHeight, want)
+		}
+	}
+	return nil
+}
+
+// checkBlockFinal checks that every tx in b was final at b's height and time.
+func checkBlockFinal(b models.Block) error {
+	for _, tx := range b.Transactions {
+		if !IsFinal(tx, b.Index, b.Timestamp) {
+			return fmt.Errorf("tx %s is locked until %s", tx.ID, describeLock(tx))
+		}
+	}
+	return nil
+}
+
+// NextHeight is the height of the block that would extend the current tip.
+func NextHeight(ctx context.Context) (int, error) {
+	var tip models.Block
+	err := db.Col("blocks").FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&tip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return tip.Index + 1, nil
+}
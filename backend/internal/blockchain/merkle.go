@@ -1,38 +1,137 @@
 package blockchain
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
 )
 
-// MerkleRoot computes a simple merkle root for a slice of transactions.
+// BlockVersionTxHashMerkle marks blocks whose merkle tree is built over
+// canonical tx hashes. Version 0 blocks hashed only id+sender+receiver.
+const BlockVersionTxHashMerkle = 1
+
+// MerkleStep is one level of a merkle branch: the sibling hash and which
+// side of the running hash it sits on.
+type MerkleStep struct {
+	Hash     string `json:"hash"`
+	Position string `json:"position"` // "left" or "right"
+}
+
+// MerkleRoot computes the merkle root over the canonical hashes of txs.
 func MerkleRoot(txs []models.Transaction) string {
-	if len(txs) == 0 {
-		return ""
+	return merkleRoot(txLeaves(txs))
+}
+
+// BlockMerkleRoot computes the merkle root the way b's version defines it.
+func BlockMerkleRoot(b models.Block) string {
+	return merkleRoot(blockLeaves(b))
+}
+
+// BlockMerkleProof returns the leaf hash of the tx at index in b and the
+// branch linking it to b.MerkleRoot.
+func BlockMerkleProof(b models.Block, index int) (string, []MerkleStep, error) {
+	leaves := blockLeaves(b)
+	if index < 0 || index >= len(leaves) {
+		return "", nil, errors.New("tx index out of range")
+	}
+
+	var proof []MerkleStep
+	level, pos := leaves, index
+	for len(level) > 1 {
+		switch {
+		case pos%2 == 1:
+			proof = append(proof, MerkleStep{Hash: hex.EncodeToString(level[pos-1]), Position: "left"})
+		case pos+1 < len(level):
+			proof = append(proof, MerkleStep{Hash: hex.EncodeToString(level[pos+1]), Position: "right"})
+		}
+		// a lone last node is carried up unchanged and adds no step
+		level = merkleLevel(level)
+		pos /= 2
+	}
+	return hex.EncodeToString(leaves[index]), proof, nil
+}
+
+// VerifyMerkleProof folds a branch onto leafHash and reports whether it
+// reproduces root. Needs only the block header, not the block body.
+func VerifyMerkleProof(leafHash string, proof []MerkleStep, root string) bool {
+	cur, err := hex.DecodeString(leafHash)
+	if err != nil {
+		return false
+	}
+	for _, step := range proof {
+		sib, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false
+		}
+		switch step.Position {
+		case "left":
+			cur = hashPair(sib, cur)
+		case "right":
+			cur = hashPair(cur, sib)
+		default:
+			return false
+		}
 	}
+	want, err := hex.DecodeString(root)
+	return err == nil && bytes.Equal(cur, want)
+}
 
-	var hashes [][]byte
+func blockLeaves(b models.Block) [][]byte {
+	if b.Version >= BlockVersionTxHashMerkle {
+		return txLeaves(b.Transactions)
+	}
+	return legacyLeaves(b.Transactions)
+}
+
+func txLeaves(txs []models.Transaction) [][]byte {
+	var leaves [][]byte
+	for _, tx := range txs {
+		h := sha256.Sum256(CanonicalTxBytes(tx))
+		leaves = append(leaves, h[:])
+	}
+	return leaves
+}
+
+func legacyLeaves(txs []models.Transaction) [][]byte {
+	var leaves [][]byte
 	for _, tx := range txs {
 		h := sha256.Sum256([]byte(tx.ID + tx.SenderWallet + tx.ReceiverWallet))
-		hashes = append(hashes, h[:])
+		leaves = append(leaves, h[:])
 	}
+	return leaves
+}
 
+func merkleRoot(hashes [][]byte) string {
+	if len(hashes) == 0 {
+		return ""
+	}
 	for len(hashes) > 1 {
-		var next [][]byte
-		for i := 0; i < len(hashes); i += 2 {
-			if i+1 == len(hashes) {
-				// odd number of nodes, duplicate last
-				next = append(next, hashes[i])
-			} else {
-				concat := append(hashes[i], hashes[i+1]...)
-				h := sha256.Sum256(concat)
-				next = append(next, h[:])
-			}
+		hashes = merkleLevel(hashes)
+	}
+	return hex.EncodeToString(hashes[0])
+}
+
+// merkleLevel hashes pairs of nodes into the next level up.
+func merkleLevel(hashes [][]byte) [][]byte {
+	var next [][]byte
+	for i := 0; i < len(hashes); i += 2 {
+		if i+1 == len(hashes) {
+			// odd number of nodes, carry the last one up
+			next = append(next, hashes[i])
+		} else {
+			next = append(next, hashPair(hashes[i], hashes[i+1]))
 		}
-		hashes = next
 	}
+	return next
+}
 
-	return hex.EncodeToString(hashes[0])
+func hashPair(left, right []byte) []byte {
+	concat := make([]byte, 0, len(left)+len(right))
+	concat = append(concat, left...)
+	concat = append(concat, right...)
+	h := sha256.Sum256(concat)
+	return h[:]
 }
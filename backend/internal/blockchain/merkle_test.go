@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+func TestMerkleProofs(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		b := models.Block{Version: BlockVersionTxHashMerkle}
+		for i := range n {
+			b.Transactions = append(b.Transactions, models.Transaction{
+				ID:           fmt.Sprintf("tx%d", i),
+				SenderWallet: "a",
+				Amount:       models.Amount(i + 1),
+			})
+		}
+		root := BlockMerkleRoot(b)
+		if root != MerkleRoot(b.Transactions) {
+			t.Fatalf("%d txs: BlockMerkleRoot and MerkleRoot differ", n)
+		}
+
+		for i := range n {
+			leaf, proof, err := BlockMerkleProof(b, i)
+			if err != nil {
+				t.Fatalf("%d txs: proof of %d: %v", n, i, err)
+			}
+			if !VerifyMerkleProof(leaf, proof, root) {
+				t.Errorf("%d txs: proof of %d does not verify", n, i)
+			}
+			other := (i + 1) % n
+			if otherLeaf, _, _ := BlockMerkleProof(b, other); other != i && VerifyMerkleProof(otherLeaf, proof, root) {
+				t.Errorf("%d txs: proof of %d verifies tx %d", n, i, other)
+			}
+			if len(proof) > 0 {
+				bad := append([]MerkleStep(nil), proof...)
+				bad[0].Position = map[string]string{"left": "right", "right": "left"}[bad[0].Position]
+				if VerifyMerkleProof(leaf, bad, root) {
+					t.Errorf("%d txs: proof of %d verifies with a flipped step", n, i)
+				}
+			}
+		}
+		if _, _, err := BlockMerkleProof(b, n); err == nil {
+			t.Errorf("%d txs: proof of index %d succeeded", n, n)
+		}
+	}
+}
+
+// With three leaves the last one is carried up unpaired.
+func TestMerkleRootOddLeaf(t *testing.T) {
+	txs := []models.Transaction{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	l := txLeaves(txs)
+	want := hex.EncodeToString(hashPair(hashPair(l[0], l[1]), l[2]))
+	if got := MerkleRoot(txs); got != want {
+		t.Errorf("MerkleRoot = %s, want %s", got, want)
+	}
+	if got := MerkleRoot(txs[:1]); got != hex.EncodeToString(l[0]) {
+		t.Errorf("MerkleRoot of one tx = %s, want its leaf", got)
+	}
+	if got := MerkleRoot(nil); got != "" {
+		t.Errorf("MerkleRoot(nil) = %q, want empty", got)
+	}
+}
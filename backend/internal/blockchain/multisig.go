@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// verifyMultisig checks that the sender of tx is the wallet its multisig
+// policy derives and that at least threshold members signed. Every
+// signature present must be valid, not just enough of them.
+func verifyMultisig(tx models.Transaction) error {
+	ms := tx.Multisig
+	if tx.Version < TxVersionCanonical {
+		return errors.New("multisig spends need the canonical encoding")
+	}
+	if tx.SenderPubKey != "" || tx.Signature != "" {
+		return errors.New("multisig spend carries a single-key signature")
+	}
+
+	n := len(ms.PublicKeys)
+	if ms.Threshold < 1 || ms.Threshold > n {
+		return fmt.Errorf("multisig threshold %d of %d keys", ms.Threshold, n)
+	}
+	if len(ms.Signatures) != n {
+		return fmt.Errorf("multisig has %d signature slots for %d keys", len(ms.Signatures), n)
+	}
+	for i := 1; i < n; i++ {
+		if ms.PublicKeys[i-1] >= ms.PublicKeys[i] {
+			return errors.New("multisig public keys must be sorted and distinct")
+		}
+	}
+	if appCrypto.MultisigWalletID(ms.Threshold, ms.PublicKeys) != tx.SenderWallet {
+		return errors.New("multisig policy does not match sender wallet")
+	}
+
+	payload := SigningPayload(tx)
+	signed := 0
+	for i, sig := range ms.Signatures {
+		if sig == "" {
+			continue
+		}
+		if err := VerifyMemberSignature(ms.PublicKeys[i], payload, sig); err != nil {
+			return fmt.Errorf("multisig key %d: %w", i, err)
+		}
+		signed++
+	}
+	if signed < ms.Threshold {
+		return fmt.Errorf("multisig has %d of %d required signatures", signed, ms.Threshold)
+	}
+	return nil
+}
+
+// VerifyMemberSignature checks one member's signature over a signing payload.
+func VerifyMemberSignature(pubKeyHex, payload, sig string) error {
+	pub, err := appCrypto.PublicFromHex(pubKeyHex)
+	if err != nil {
+		return fmt.Errorf("bad public key: %w", err)
+	}
+	if ok, err := appCrypto.VerifySignature(*pub, payload, sig); !ok {
+		return fmt.Errorf("bad signature: %v", err)
+	}
+	return nil
+}
@@ -1,15 +1,22 @@
 package blockchain
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
 )
 
+// ctxCheckEvery is how many nonces a worker tries between cancellation checks.
+const ctxCheckEvery = 256
+
 func calculateBlockHash(b models.Block) string {
 	data := fmt.Sprintf("%d|%s|%s|%d|%s",
 		b.Index,
@@ -18,6 +25,10 @@ func calculateBlockHash(b models.Block) string {
 		b.Nonce,
 		b.MerkleRoot,
 	)
+	if b.Version >= BlockVersionRetarget {
+		// commit to version and target so neither can be altered without re-mining
+		data += fmt.Sprintf("|%d|%d", b.Version, b.Bits)
+	}
 	h := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(h[:])
 }
@@ -27,15 +38,70 @@ func hasRequiredDifficulty(hash string) bool {
 	return strings.HasPrefix(hash, prefix)
 }
 
+// hasValidPoW checks a block hash against the target the block declares,
+// or the legacy hex-zero prefix for blocks from before retargeting.
+func hasValidPoW(b models.Block, hash string) bool {
+	if b.Version >= BlockVersionRetarget {
+		return meetsTarget(hash, CompactToBig(b.Bits))
+	}
+	return hasRequiredDifficulty(hash)
+}
+
+// MineBlock searches for a valid nonce using every available CPU.
 func MineBlock(b *models.Block) {
-	nonce := int64(0)
-	for {
-		b.Nonce = nonce
-		h := calculateBlockHash(*b)
-		if hasRequiredDifficulty(h) {
-			b.Hash = h
-			return
-		}
-		nonce++
+	_ = MineBlockContext(context.Background(), b, runtime.GOMAXPROCS(0), nil)
+}
+
+// MineBlockContext searches for a nonce meeting b's target with workers
+// goroutines, worker i trying nonces i, i+workers, i+2*workers, ...
+// On success b.Nonce and b.Hash are set. It returns ctx.Err() if ctx is
+// cancelled or times out first. Every hash tried is counted in attempts,
+// which may be nil.
+func MineBlockContext(ctx context.Context, b *models.Block, workers int, attempts *atomic.Uint64) error {
+	if workers < 1 {
+		workers = 1
+	}
+	b.Hash = ""
+
+	searchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var (
+		once       sync.Once
+		wg         sync.WaitGroup
+		foundHash  string
+		foundNonce int64
+	)
+	for w := 0; w < workers; w++ {
+		cand := *b
+		wg.Add(1)
+		go func(start int64) {
+			defer wg.Done()
+			for nonce, n := start, 0; ; nonce, n = nonce+int64(workers), n+1 {
+				if n%ctxCheckEvery == 0 && searchCtx.Err() != nil {
+					return
+				}
+				cand.Nonce = nonce
+				h := calculateBlockHash(cand)
+				if attempts != nil {
+					attempts.Add(1)
+				}
+				if hasValidPoW(cand, h) {
+					once.Do(func() {
+						foundHash, foundNonce = h, nonce
+						stop()
+					})
+					return
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	if foundHash == "" {
+		return ctx.Err()
 	}
+	b.Nonce = foundNonce
+	b.Hash = foundHash
+	return nil
 }
@@ -0,0 +1,65 @@
+package blockchain
+
+import (
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// maxHalvings is where the subsidy has long reached zero; shifting further
+// is pointless.
+const maxHalvings = 64
+
+// scheduledSubsidy is the halving schedule before the supply cap: the
+// initial reward halved once every HalvingInterval blocks, rounded down
+// to whole base units.
+func scheduledSubsidy(height int) models.Amount {
+	halvings := height / config.AppConfig.HalvingInterval
+	if halvings >= maxHalvings {
+		return 0
+	}
+	return config.AppConfig.InitialReward >> uint(halvings)
+}
+
+// scheduledIssued is the most the schedule can have created in blocks
+// 0..height-1, ignoring the supply cap.
+func scheduledIssued(height int) models.Amount {
+	interval := config.AppConfig.HalvingInterval
+	var total models.Amount
+	for era := 0; era < maxHalvings && era*interval < height; era++ {
+		blocks := interval
+		if rest := height - era*interval; rest < blocks {
+			blocks = rest
+		}
+		total += models.Amount(blocks) * scheduledSubsidy(era*interval)
+	}
+	return total
+}
+
+// Subsidy is the new money a block at height may create in its coinbase,
+// on top of the fees it collects. It follows the halving schedule and
+// stops once MaxSupply would be exceeded.
+func Subsidy(height int) models.Amount {
+	s := scheduledSubsidy(height)
+	if left := config.AppConfig.MaxSupply - scheduledIssued(height); s > left {
+		s = max(left, 0)
+	}
+	return s
+}
+
+// MaxMoney bounds every sum of amounts a tx or block may carry: no more
+// than the total supply can ever exist.
+func MaxMoney() models.Amount {
+	return config.AppConfig.MaxSupply
+}
+
+// IssuedThrough is the most subsidy blocks 0..height can have created.
+func IssuedThrough(height int) models.Amount {
+	return min(scheduledIssued(height+1), config.AppConfig.MaxSupply)
+}
+
+// NextHalving returns the first height after height at which the scheduled
+// subsidy halves.
+func NextHalving(height int) int {
+	interval := config.AppConfig.HalvingInterval
+	return (height/interval + 1) * interval
+}
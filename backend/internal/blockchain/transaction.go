@@ -0,0 +1,190 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// TxVersionCanonical marks transactions whose id and signature are
+// derived from CanonicalTxBytes. Version 0 is the legacy string payload.
+const TxVersionCanonical = 1
+
+type canonicalInput struct {
+	UTXOId string `json:"utxo_id"`
+	Index  int    `json:"index"`
+}
+
+type canonicalOutput struct {
+	OwnerWallet    string             `json:"owner_wallet"`
+	Amount         string             `json:"amount"`
+	MaturityHeight int                `json:"maturity_height,omitempty"`
+	Lock           *models.OutputLock `json:"lock,omitempty"` // plain fields, encoded in declaration order
+}
+
+// canonicalTx fixes the field order of the encoding; amounts are decimal
+// strings with 8 fractional digits (models.Amount.String).
+type canonicalTx struct {
+	Version        int               `json:"version"`
+	Type           string            `json:"type"`
+	SenderWallet   string            `json:"sender_wallet"`
+	ReceiverWallet string            `json:"receiver_wallet"`
+	Amount         string            `json:"amount"`
+	Note           string            `json:"note"`
+	Timestamp      int64             `json:"timestamp"` // unix milliseconds
+	SenderPubKey   string            `json:"sender_public_key"`
+	Inputs         []canonicalInput  `json:"inputs"`
+	Outputs        []canonicalOutput `json:"outputs"`
+	Fee            string            `json:"fee,omitempty"` // omitted when zero so fee-less ids are unchanged
+	LockTime       int64             `json:"lock_time,omitempty"`
+	Multisig       *canonicalPolicy  `json:"multisig,omitempty"`
+	Preimage       string            `json:"preimage,omitempty"`
+}
+
+// canonicalPolicy commits a multisig spend to its policy; the member
+// signatures are collected afterwards and left out.
+type canonicalPolicy struct {
+	Threshold  int      `json:"threshold"`
+	PublicKeys []string `json:"public_keys"`
+}
+
+// CanonicalTxBytes is the deterministic encoding of everything a tx commits
+// to. Id, signature, status and block id are excluded.
+func CanonicalTxBytes(tx models.Transaction) []byte {
+	ct := canonicalTx{
+		Version:        tx.Version,
+		Type:           tx.Type,
+		SenderWallet:   tx.SenderWallet,
+		ReceiverWallet: tx.ReceiverWallet,
+		Amount:         tx.Amount.String(),
+		Note:           tx.Note,
+		Timestamp:      tx.Timestamp.UnixMilli(),
+		SenderPubKey:   tx.SenderPubKey,
+		LockTime:       tx.LockTime,
+		Preimage:       tx.Preimage,
+		Inputs:         make([]canonicalInput, 0, len(tx.Inputs)),
+		Outputs:        make([]canonicalOutput, 0, len(tx.Outputs)),
+	}
+	for _, in := range tx.Inputs {
+		ct.Inputs = append(ct.Inputs, canonicalInput{UTXOId: in.UTXOId, Index: in.Index})
+	}
+	for _, o := range tx.Outputs {
+		ct.Outputs = append(ct.Outputs, canonicalOutput{
+			OwnerWallet:    o.OwnerWallet,
+			Amount:         o.Amount.String(),
+			MaturityHeight: o.MaturityHeight,
+			Lock:           o.Lock,
+		})
+	}
+
+	if tx.Fee != 0 {
+		ct.Fee = tx.Fee.String()
+	}
+	if tx.Multisig != nil {
+		ct.Multisig = &canonicalPolicy{Threshold: tx.Multisig.Threshold, PublicKeys: tx.Multisig.PublicKeys}
+	}
+
+	// marshalling plain strings, ints and slices cannot fail
+	b, _ := json.Marshal(ct)
+	return b
+}
+
+// TxHash is the content address of a transaction: hex SHA-256 of its
+// canonical encoding.
+func TxHash(tx models.Transaction) string {
+	h := sha256.Sum256(CanonicalTxBytes(tx))
+	return hex.EncodeToString(h[:])
+}
+
+// TxSize is the number of bytes a tx occupies in a block: its canonical
+// encoding plus the raw signatures.
+func TxSize(tx models.Transaction) int {
+	size := len(CanonicalTxBytes(tx)) + len(tx.Signature)/2
+	if tx.Multisig != nil {
+		for _, sig := range tx.Multisig.Signatures {
+			size += len(sig) / 2
+		}
+	}
+	return size
+}
+
+// SealTx stamps a transaction as canonical and sets its content-addressed id.
+// The timestamp is truncated to what Mongo stores so the id survives a round trip.
+func SealTx(tx *models.Transaction) {
+	tx.Version = TxVersionCanonical
+	tx.Timestamp = tx.Timestamp.UTC().Truncate(time.Millisecond)
+	tx.ID = TxHash(*tx)
+}
+
+// VerifyTxID checks that a canonical tx's id is the hash of its contents.
+// Legacy txs got their ids from Mongo and can't be checked.
+func VerifyTxID(tx models.Transaction) error {
+	if tx.Version < TxVersionCanonical {
+		return nil
+	}
+	if h := TxHash(tx); h != tx.ID {
+		return fmt.Errorf("tx id %s does not match content hash %s", tx.ID, h)
+	}
+	return nil
+}
+
+// SigningPayload returns the message a sender signs for a transaction.
+func SigningPayload(tx models.Transaction) string {
+	if tx.Version >= TxVersionCanonical {
+		return string(CanonicalTxBytes(tx))
+	}
+	return tx.SenderWallet + tx.ReceiverWallet + fmt.Sprintf("%f", tx.Amount.Coins()) + tx.Timestamp.UTC().Format(time.RFC3339) + tx.Note
+}
+
+// VerifyTxSignature checks tx.Signature against tx.SenderPubKey and that
+// the public key derives the sender wallet id, or for a multisig spend
+// its member signatures (see verifyMultisig).
+func VerifyTxSignature(tx models.Transaction) error {
+	if tx.Multisig != nil {
+		return verifyMultisig(tx)
+	}
+	if tx.SenderPubKey == "" || tx.Signature == "" {
+		return errors.New("missing signature or sender public key")
+	}
+	pub, err := appCrypto.PublicFromHex(tx.SenderPubKey)
+	if err != nil {
+		return fmt.Errorf("bad sender public key: %w", err)
+	}
+	if appCrypto.WalletIDFromPublicKey(*pub) != tx.SenderWallet {
+		return errors.New("sender public key does not match sender wallet")
+	}
+	if ok, err := appCrypto.VerifySignature(*pub, SigningPayload(tx), tx.Signature); !ok {
+		return fmt.Errorf("bad signature: %v", err)
+	}
+	return nil
+}
+
+// IsCoinbase reports whether tx is a block's mining reward.
+func IsCoinbase(tx models.Transaction) bool {
+	return tx.Type == "mining_reward"
+}
+
+// feeMatches reports whether a declared fee equals the value left over
+// by a tx with the given input and output totals.
+func feeMatches(tx models.Transaction, inTotal, outTotal models.Amount) bool {
+	return inTotal-outTotal == tx.Fee
+}
+
+// OutputsTotal sums a transaction's outputs, failing with ErrInvalidTx
+// rather than wrapping around when they add up to more than MaxMoney.
+func OutputsTotal(tx models.Transaction) (models.Amount, error) {
+	var total models.Amount
+	for _, o := range tx.Outputs {
+		var err error
+		if total, err = models.AddAmounts(total, o.Amount, MaxMoney()); err != nil {
+			return 0, invalidTx("outputs: %v", err)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,41 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// outputs of 2^62, 2^62, 2^62 and 2^62+T used to wrap around to T, so a
+// small input passed the outputs-vs-inputs and fee checks while minting
+// about 1.8e11 coins.
+func TestOutputsTotalRejectsOverflow(t *testing.T) {
+	config.AppConfig = &config.Config{MaxSupply: 21000000 * models.Coin}
+
+	const huge = models.Amount(1) << 62
+	tx := models.Transaction{Outputs: []models.TxUTXOOutput{
+		{Amount: huge}, {Amount: huge}, {Amount: huge}, {Amount: huge + 999900000},
+	}}
+	out, err := OutputsTotal(tx)
+	if !errors.Is(err, ErrInvalidTx) {
+		t.Fatalf("OutputsTotal = %s, %v, want ErrInvalidTx", out, err)
+	}
+
+	tx.Outputs = []models.TxUTXOOutput{{Amount: 15 * models.Coin}, {Amount: 5 * models.Coin}}
+	out, err = OutputsTotal(tx)
+	if err != nil || out != 20*models.Coin {
+		t.Fatalf("OutputsTotal = %s, %v, want 20", out, err)
+	}
+	tx.Fee = models.Coin
+	if !feeMatches(tx, 21*models.Coin, out) {
+		t.Fatal("feeMatches rejected a fee of inputs minus outputs")
+	}
+
+	// outputs above the total supply are invalid even without wrapping
+	tx.Outputs = []models.TxUTXOOutput{{Amount: 21000000 * models.Coin}, {Amount: 1}}
+	if _, err := OutputsTotal(tx); !errors.Is(err, ErrInvalidTx) {
+		t.Fatalf("OutputsTotal above MaxMoney: err = %v, want ErrInvalidTx", err)
+	}
+}
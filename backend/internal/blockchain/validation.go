@@ -1,12 +1,33 @@
 package blockchain
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func ValidateBlock(b models.Block, prev *models.Block) error {
+// ErrInvalidTx wraps every rule a transaction can break. Errors that
+// don't wrap it (db failures) say nothing about the tx itself.
+var ErrInvalidTx = errors.New("invalid transaction")
+
+// ErrDoubleSpend is returned when a tx input is already spent or is
+// claimed by another tx in the same block.
+var ErrDoubleSpend = errors.New("double spend")
+
+func invalidTx(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %w", ErrInvalidTx, fmt.Errorf(format, args...))
+}
+
+// ValidateBlock checks a block header against its predecessor: linkage,
+// hash, proof-of-work, merkle root, tx lock times and the coinbase against
+// the monetary policy. prev is nil for the genesis block.
+// expectedBits is the target in force at b's height (see ExpectedBits).
+func ValidateBlock(b models.Block, prev *models.Block, expectedBits uint32) error {
 	if prev != nil {
 		if b.PreviousHash != prev.Hash {
 			return errors.New("invalid previous hash")
@@ -14,7 +35,178 @@ func ValidateBlock(b models.Block, prev *models.Block) error {
 		if b.Index != prev.Index+1 {
 			return errors.New("invalid index")
 		}
+	} else {
+		if b.Index != 0 || b.PreviousHash != "" {
+			return errors.New("invalid genesis block")
+		}
+	}
+
+	if h := calculateBlockHash(b); h != b.Hash {
+		return fmt.Errorf("hash mismatch: stored %s, computed %s", b.Hash, h)
+	}
+	if b.Version >= BlockVersionRetarget && b.Bits != expectedBits {
+		return fmt.Errorf("target bits %08x, expected %08x", b.Bits, expectedBits)
+	}
+	if prev != nil && prev.Version >= BlockVersionRetarget && b.Version < BlockVersionRetarget {
+		return errors.New("block version below retarget activation")
+	}
+	if prev != nil && prev.Version >= BlockVersionTimeLocks && b.Version < BlockVersionTimeLocks {
+		return errors.New("block version below time-lock activation")
+	}
+	if !hasValidPoW(b, b.Hash) {
+		return errors.New("hash does not meet proof-of-work target")
+	}
+	if root := BlockMerkleRoot(b); root != b.MerkleRoot {
+		return fmt.Errorf("merkle root mismatch: stored %s, computed %s", b.MerkleRoot, root)
+	}
+	if err := checkBlockFinal(b); err != nil {
+		return err
+	}
+	return CheckCoinbase(b)
+}
+
+// CheckCoinbase checks that b starts with its only coinbase, that the
+// coinbase pays no more than the subsidy at b's height plus the fees declared by
+// b's other transactions and, from BlockVersionTimeLocks on, that its outputs
+// mature late enough. Declared fees are checked against inputs by
+// ValidateTransaction.
+func CheckCoinbase(b models.Block) error {
+	if len(b.Transactions) == 0 || !IsCoinbase(b.Transactions[0]) {
+		return errors.New("first transaction is not a coinbase")
+	}
+
+	fees := Subsidy(b.Index)
+	for _, tx := range b.Transactions[1:] {
+		if IsCoinbase(tx) {
+			return fmt.Errorf("tx %s: extra coinbase", tx.ID)
+		}
+		var err error
+		if fees, err = models.AddAmounts(fees, tx.Fee, MaxMoney()); err != nil {
+			return fmt.Errorf("tx %s: fees: %w", tx.ID, err)
+		}
+	}
+	paid, err := coinbaseValue(b.Transactions[0])
+	if err != nil {
+		return err
+	}
+	if paid > fees {
+		return fmt.Errorf("coinbase pays %s, above subsidy plus fees %s", paid, fees)
+	}
+	return checkCoinbaseMaturity(b)
+}
+
+// coinbaseValue is what a coinbase credits: its outputs, or its amount for
+// legacy coinbases without outputs.
+func coinbaseValue(tx models.Transaction) (models.Amount, error) {
+	out, err := OutputsTotal(tx)
+	if err != nil {
+		return 0, err
+	}
+	return max(out, tx.Amount), nil
+}
+
+// ValidateTransaction fully re-checks a user transaction before it enters
+// the pending pool or a block at height with timestamp blockTime: content
+// id, signature, sender wallet derivation, input ownership, that inputs
+// cover outputs and that its lock time and inputs' maturity allow it in.
+// claimed holds UTXOs already spent by earlier txs in the same block; the
+// tx's inputs are added to it when it is valid. Returns the input total,
+// also alongside an ErrNotFinal error when only the time locks fail.
+func ValidateTransaction(ctx context.Context, tx models.Transaction, height int, blockTime time.Time, claimed map[string]bool) (models.Amount, error) {
+	if IsCoinbase(tx) {
+		return 0, invalidTx("coinbase outside block header position")
+	}
+	if err := VerifyTxID(tx); err != nil {
+		return 0, invalidTx("%v", err)
+	}
+	if err := VerifyTxSignature(tx); err != nil {
+		return 0, invalidTx("%v", err)
+	}
+	if len(tx.Outputs) == 0 {
+		return 0, invalidTx("no outputs")
+	}
+	for _, o := range tx.Outputs {
+		if o.Amount <= 0 {
+			return 0, invalidTx("output amounts must be positive")
+		}
+	}
+	if err := checkLocks(tx); err != nil {
+		return 0, err
+	}
+
+	if err := checkOutputLocks(tx); err != nil {
+		return 0, err
+	}
+
+	inTotal, maturity, err := resolveInputs(ctx, tx, height, claimed)
+	if err != nil {
+		return 0, err
+	}
+	out, err := OutputsTotal(tx)
+	if err != nil {
+		return 0, err
+	}
+	if out > inTotal {
+		return 0, invalidTx("outputs %s exceed inputs %s", out, inTotal)
+	}
+	if !feeMatches(tx, inTotal, out) {
+		return 0, invalidTx("declared fee %s, inputs minus outputs is %s", tx.Fee, inTotal-out)
+	}
+	if !IsFinal(tx, height, blockTime) {
+		return inTotal, fmt.Errorf("%w: locked until %s", ErrNotFinal, describeLock(tx))
+	}
+	if maturity > height {
+		return inTotal, fmt.Errorf("%w: spends an output maturing at height %d", ErrNotFinal, maturity)
+	}
+
+	for _, in := range tx.Inputs {
+		claimed[in.UTXOId] = true
+	}
+	return inTotal, nil
+}
+
+// resolveInputs checks that every input of tx references an existing,
+// unspent UTXO the sender may spend at height (see spendHeight) that no
+// earlier tx in the block has claimed. It returns the summed input value
+// and the first height all inputs may be spent from.
+func resolveInputs(ctx context.Context, tx models.Transaction, height int, claimed map[string]bool) (models.Amount, int, error) {
+	if len(tx.Inputs) == 0 {
+		return 0, 0, invalidTx("no inputs")
+	}
+
+	var total models.Amount
+	maturity, htlcs := 0, 0
+	seen := map[string]bool{}
+	for _, in := range tx.Inputs {
+		if claimed[in.UTXOId] || seen[in.UTXOId] {
+			return 0, 0, invalidTx("%w: utxo %s", ErrDoubleSpend, in.UTXOId)
+		}
+		seen[in.UTXOId] = true
+
+		var u models.UTXO
+		if err := db.Col("utxos").FindOne(ctx, db.ByID(in.UTXOId)).Decode(&u); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return 0, 0, invalidTx("input utxo %s not found", in.UTXOId)
+			}
+			return 0, 0, err
+		}
+		if u.IsSpent {
+			return 0, 0, invalidTx("%w: utxo %s spent in tx %s", ErrDoubleSpend, in.UTXOId, u.SpentInTxID)
+		}
+		from, err := spendHeight(tx, u, height)
+		if err != nil {
+			return 0, 0, invalidTx("%v", err)
+		}
+		if u.Lock != nil {
+			htlcs++
+		}
+		if total, err = models.AddAmounts(total, u.Amount, MaxMoney()); err != nil {
+			return 0, 0, invalidTx("inputs: %v", err)
+		}
+		maturity = max(maturity, from)
+	}
+	if isHTLCSpend(tx) && htlcs == 0 {
+		return 0, 0, invalidTx("%s spends no HTLC", tx.Type)
 	}
-	// check PoW, tx, etc...
-	return nil
+	return total, maturity, nil
 }
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
 	"github.com/joho/godotenv"
 )
 
@@ -12,9 +16,27 @@ type Config struct {
 	MongoURI      string
 	DBName        string
 	JWTSecret     string
-	AESSecretKey  string
+	AESSecretKey  string            // decrypts legacy values without a key id
+	AESKeys       map[string]string // key id -> 64 hex chars, from AES_KEYS
+	AESActiveKey  string            // id of the key new values are encrypted with
 	ZakatWalletID string
-	PowDifficulty int
+	PowDifficulty int // leading hex zeros; initial difficulty before retargeting
+
+	TargetBlockTime  time.Duration // desired spacing between blocks
+	RetargetInterval int           // blocks between difficulty adjustments
+	MiningTimeout    time.Duration // a mining job is abandoned after this long
+
+	MaxBlockTxs   int           // user txs per block
+	MaxBlockBytes int           // summed tx size per block
+	MempoolMaxAge time.Duration // pending txs older than this are evicted
+	BaseFeeRate   models.Amount // suggested fee per 1000 bytes while blocks have room
+
+	InitialReward    models.Amount // coinbase subsidy of the first era
+	HalvingInterval  int           // blocks per subsidy era
+	MaxSupply        models.Amount // > 0; subsidy stops once this much is issued, and it caps every tx sum
+	CoinbaseMaturity int           // blocks before a coinbase output can be spent
+
+	HDGapLimit int // unused receive addresses in a row that end a restore scan
 }
 
 var AppConfig *Config
@@ -27,16 +49,134 @@ func LoadConfig() {
 		diff = 5
 	}
 
+	targetSecs, err := strconv.Atoi(os.Getenv("TARGET_BLOCK_SECONDS"))
+	if err != nil || targetSecs <= 0 {
+		targetSecs = 60
+	}
+
+	retarget, err := strconv.Atoi(os.Getenv("RETARGET_INTERVAL"))
+	if err != nil || retarget < 2 {
+		retarget = 10
+	}
+
+	miningTimeout, err := strconv.Atoi(os.Getenv("MINING_TIMEOUT_SECONDS"))
+	if err != nil || miningTimeout <= 0 {
+		miningTimeout = 600
+	}
+
+	maxBlockTxs, err := strconv.Atoi(os.Getenv("MAX_BLOCK_TXS"))
+	if err != nil || maxBlockTxs <= 0 {
+		maxBlockTxs = 500
+	}
+
+	maxBlockBytes, err := strconv.Atoi(os.Getenv("MAX_BLOCK_BYTES"))
+	if err != nil || maxBlockBytes <= 0 {
+		maxBlockBytes = 1000000
+	}
+
+	mempoolMaxAge, err := strconv.Atoi(os.Getenv("MEMPOOL_MAX_AGE_HOURS"))
+	if err != nil || mempoolMaxAge <= 0 {
+		mempoolMaxAge = 72
+	}
+
+	baseFeeRate, err := models.ParseAmount(os.Getenv("BASE_FEE_RATE"))
+	if err != nil || baseFeeRate < 0 {
+		baseFeeRate = models.Coin / 10000
+	}
+
+	initialReward, err := models.ParseAmount(os.Getenv("INITIAL_BLOCK_REWARD"))
+	if err != nil || initialReward < 0 {
+		initialReward = 50 * models.Coin
+	}
+
+	halvingInterval, err := strconv.Atoi(os.Getenv("HALVING_INTERVAL"))
+	if err != nil || halvingInterval <= 0 {
+		halvingInterval = 210000
+	}
+
+	// MAX_SUPPLY is a cap, there is no unlimited setting: 0 would mean no
+	// subsidy at all, so anything but a positive amount is refused
+	maxSupply := 21000000 * models.Coin
+	if v := os.Getenv("MAX_SUPPLY"); v != "" {
+		if maxSupply, err = models.ParseAmount(v); err != nil || maxSupply <= 0 {
+			log.Fatalf("MAX_SUPPLY %q must be a positive amount", v)
+		}
+	}
+
+	coinbaseMaturity, err := strconv.Atoi(os.Getenv("COINBASE_MATURITY"))
+	if err != nil || coinbaseMaturity < 0 {
+		coinbaseMaturity = 100
+	}
+
+	gapLimit, err := strconv.Atoi(os.Getenv("HD_GAP_LIMIT"))
+	if err != nil || gapLimit <= 0 {
+		gapLimit = 20
+	}
+
+	aesKeys, err := parseAESKeys(os.Getenv("AES_KEYS"))
+	if err != nil {
+		log.Fatal("AES_KEYS: ", err)
+	}
+	activeKey := os.Getenv("AES_ACTIVE_KEY_ID")
+	if activeKey == "" && len(aesKeys) == 1 {
+		for id := range aesKeys {
+			activeKey = id
+		}
+	}
+	if _, ok := aesKeys[activeKey]; activeKey != "" && !ok {
+		log.Fatalf("AES_ACTIVE_KEY_ID %q is not in AES_KEYS", activeKey)
+	}
+
 	AppConfig = &Config{
 		MongoURI:      os.Getenv("MONGODB_URI"),
 		DBName:        os.Getenv("DB_NAME"),
 		JWTSecret:     os.Getenv("JWT_SECRET"),
 		AESSecretKey:  os.Getenv("AES_SECRET_KEY"),
+		AESKeys:       aesKeys,
+		AESActiveKey:  activeKey,
 		ZakatWalletID: os.Getenv("ZAKAT_WALLET_ID"),
 		PowDifficulty: diff,
+
+		TargetBlockTime:  time.Duration(targetSecs) * time.Second,
+		RetargetInterval: retarget,
+		MiningTimeout:    time.Duration(miningTimeout) * time.Second,
+
+		MaxBlockTxs:   maxBlockTxs,
+		MaxBlockBytes: maxBlockBytes,
+		MempoolMaxAge: time.Duration(mempoolMaxAge) * time.Hour,
+		BaseFeeRate:   baseFeeRate,
+
+		InitialReward:    initialReward,
+		HalvingInterval:  halvingInterval,
+		MaxSupply:        maxSupply,
+		CoinbaseMaturity: coinbaseMaturity,
+
+		HDGapLimit: gapLimit,
 	}
 
 	if AppConfig.MongoURI == "" {
 		log.Fatal("MONGODB_URI not set")
 	}
 }
+
+// parseAESKeys reads AES_KEYS, a comma-separated list of id:hexkey pairs.
+// Ids end up in ciphertexts, so they may not contain ':'.
+func parseAESKeys(v string) (map[string]string, error) {
+	keys := map[string]string{}
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(pair, ":")
+		id, key = strings.TrimSpace(id), strings.TrimSpace(key)
+		if !ok || id == "" || key == "" || strings.Contains(key, ":") {
+			return nil, fmt.Errorf("want id:hexkey, got %q", pair)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("key id %q listed twice", id)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
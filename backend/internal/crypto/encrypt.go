@@ -8,7 +8,9 @@ import (
 	crand "crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
 )
@@ -33,8 +35,21 @@ func GenerateKeyPair() (string, string, error) {
 	return pubHex, privHex, nil
 }
 
-func getAESKey() ([]byte, error) {
-	keyBytes, err := hex.DecodeString(config.AppConfig.AESSecretKey)
+// Encrypted values are envelopes "v1:<key id>:<hex nonce+ciphertext>"
+// naming the config.AESKeys entry they were sealed with, so keys can be
+// rotated. Values from before envelopes are bare hex under AES_SECRET_KEY.
+const envelopeVersion = "v1"
+
+// getAESKey returns the key with id, or AES_SECRET_KEY for id "".
+func getAESKey(id string) ([]byte, error) {
+	keyHex := config.AppConfig.AESSecretKey
+	if id != "" {
+		var ok bool
+		if keyHex, ok = config.AppConfig.AESKeys[id]; !ok {
+			return nil, fmt.Errorf("unknown AES key id %q", id)
+		}
+	}
+	keyBytes, err := hex.DecodeString(keyHex)
 	if err != nil {
 		return nil, err
 	}
@@ -44,26 +59,50 @@ func getAESKey() ([]byte, error) {
 	return keyBytes, nil
 }
 
-// EncryptPrivateKey expects the private key as a hex string (plaintext hex),
-// decodes it and encrypts the raw bytes with AES-GCM. It returns the ciphertext
-// as a hex string (nonce + ciphertext).
-func EncryptPrivateKey(plainHex string) (string, error) {
-	key, err := getAESKey()
+// EnvelopeKeyID returns the id of the key an encrypted value was sealed
+// with, "" for a legacy value.
+func EnvelopeKeyID(encrypted string) (string, error) {
+	id, _, err := openEnvelope(encrypted)
+	return id, err
+}
+
+// openEnvelope splits an encrypted value into key id and hex body.
+func openEnvelope(encrypted string) (string, string, error) {
+	if !strings.Contains(encrypted, ":") {
+		return "", encrypted, nil
+	}
+	parts := strings.SplitN(encrypted, ":", 3)
+	if len(parts) != 3 || parts[0] != envelopeVersion || parts[1] == "" {
+		return "", "", errors.New("unsupported ciphertext envelope")
+	}
+	return parts[1], parts[2], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return cipher.NewGCM(block)
+}
 
-	plaintext, err := hex.DecodeString(plainHex)
+// EncryptPrivateKey expects the private key as a hex string (plaintext hex),
+// decodes it and encrypts the raw bytes with AES-GCM under the active key.
+// It returns the envelope holding the key id and the hex nonce + ciphertext,
+// or bare hex under AES_SECRET_KEY when no AES_KEYS are configured.
+func EncryptPrivateKey(plainHex string) (string, error) {
+	id := config.AppConfig.AESActiveKey
+	key, err := getAESKey(id)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	plaintext, err := hex.DecodeString(plainHex)
 	if err != nil {
 		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return "", err
 	}
@@ -73,29 +112,32 @@ func EncryptPrivateKey(plainHex string) (string, error) {
 		return "", err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-	return hex.EncodeToString(ciphertext), nil
+	ciphertext := hex.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil))
+	if id == "" {
+		return ciphertext, nil
+	}
+	return envelopeVersion + ":" + id + ":" + ciphertext, nil
 }
 
-// DecryptPrivateKey takes the hex-encoded ciphertext (nonce + ciphertext),
-// decrypts it using AES-GCM and returns the original private key as hex string.
-func DecryptPrivateKey(encHex string) (string, error) {
-	key, err := getAESKey()
+// DecryptPrivateKey takes an encrypted value, picks the key its envelope
+// names, decrypts it using AES-GCM and returns the original private key as
+// hex string.
+func DecryptPrivateKey(encrypted string) (string, error) {
+	id, encHex, err := openEnvelope(encrypted)
 	if err != nil {
 		return "", err
 	}
-
-	data, err := hex.DecodeString(encHex)
+	key, err := getAESKey(id)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	data, err := hex.DecodeString(encHex)
 	if err != nil {
 		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return "", err
 	}
@@ -115,3 +157,21 @@ func DecryptPrivateKey(encHex string) (string, error) {
 	// can reconstruct the ECDSA private key.
 	return hex.EncodeToString(plain), nil
 }
+
+// ReencryptPrivateKey moves an encrypted value to the active key. It
+// reports false, leaving the value alone, if it is already there.
+func ReencryptPrivateKey(encrypted string) (string, bool, error) {
+	id, err := EnvelopeKeyID(encrypted)
+	if err != nil {
+		return "", false, err
+	}
+	if id == config.AppConfig.AESActiveKey {
+		return encrypted, false, nil
+	}
+	plainHex, err := DecryptPrivateKey(encrypted)
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := EncryptPrivateKey(plainHex)
+	return rotated, err == nil, err
+}
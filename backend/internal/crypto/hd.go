@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+)
+
+// Child keys are derived from a BIP-39 seed with SLIP-10, the BIP-32
+// scheme generalised to NIST P-256. Only hardened derivation is used, so
+// every key needs the parent's private key and a leaked child key reveals
+// nothing about its siblings.
+
+// HardenedOffset is added to a child index to request hardened derivation.
+const HardenedOffset uint32 = 0x80000000
+
+// hdCoinType is the SLIP-44 coin type in receive paths. The chain has no
+// registered type of its own, so it uses the one shared by test networks.
+const hdCoinType = 1
+
+// extendedKey is a private key with the chain code its children hang off.
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+var errDerive = errors.New("hd derivation failed")
+
+// masterKey derives the root of the key tree from a seed.
+func masterKey(seed []byte) (extendedKey, error) {
+	n := elliptic.P256().Params().N
+	data := seed
+	for range 256 {
+		mac := hmac.New(sha512.New, []byte("Nist256p1 seed"))
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		k := new(big.Int).SetBytes(sum[:32])
+		if k.Sign() > 0 && k.Cmp(n) < 0 {
+			return extendedKey{key: k, chainCode: sum[32:]}, nil
+		}
+		data = sum
+	}
+	return extendedKey{}, errDerive
+}
+
+// child derives the hardened child i of k. Per SLIP-10 an out-of-range
+// result is retried with the right half of the previous HMAC.
+func (k extendedKey) child(i uint32) (extendedKey, error) {
+	n := elliptic.P256().Params().N
+	data := make([]byte, 0, 37)
+	data = append(data, 0)
+	data = append(data, k.key.FillBytes(make([]byte, 32))...)
+	data = binary.BigEndian.AppendUint32(data, i|HardenedOffset)
+	for range 256 {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(n) < 0 {
+			ki := il.Add(il, k.key)
+			ki.Mod(ki, n)
+			if ki.Sign() != 0 {
+				return extendedKey{key: ki, chainCode: sum[32:]}, nil
+			}
+		}
+		data = append([]byte{1}, sum[32:]...)
+		data = binary.BigEndian.AppendUint32(data, i|HardenedOffset)
+	}
+	return extendedKey{}, errDerive
+}
+
+// DeriveKey walks path (every step hardened) down from seed's master key.
+func DeriveKey(seed []byte, path ...uint32) (*ecdsa.PrivateKey, error) {
+	k, err := masterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range path {
+		if k, err = k.child(i); err != nil {
+			return nil, err
+		}
+	}
+	priv, _, err := PrivateFromHex(hex.EncodeToString(k.key.Bytes()))
+	return priv, err
+}
+
+// ReceivePath is the derivation path of a user's receive address index:
+// m/44'/1'/0'/0'/index'. Index 0 is the wallet created at registration.
+func ReceivePath(index int) []uint32 {
+	return []uint32{44, hdCoinType, 0, 0, uint32(index)}
+}
+
+// DeriveReceiveKey returns the key pair of receive address index in the
+// same hex encoding GenerateKeyPair uses.
+func DeriveReceiveKey(seed []byte, index int) (pubHex, privHex string, err error) {
+	if index < 0 || uint32(index) >= HardenedOffset {
+		return "", "", errors.New("receive index out of range")
+	}
+	priv, err := DeriveKey(seed, ReceivePath(index)...)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(PublicKeyToBytes(priv.PublicKey)), hex.EncodeToString(priv.D.Bytes()), nil
+}
@@ -0,0 +1,73 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// SLIP-10 test vector 1 for nist256p1.
+func TestSLIP10Vector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path      []uint32
+		key       string
+		chainCode string
+	}{
+		{
+			path:      nil, // m
+			key:       "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+			chainCode: "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea",
+		},
+		{
+			path:      []uint32{0}, // m/0H
+			key:       "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+			chainCode: "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11",
+		},
+	}
+	for _, tt := range tests {
+		k, err := masterKey(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, i := range tt.path {
+			if k, err = k.child(i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := hex.EncodeToString(k.key.FillBytes(make([]byte, 32))); got != tt.key {
+			t.Errorf("%v: key = %s, want %s", tt.path, got, tt.key)
+		}
+		if got := hex.EncodeToString(k.chainCode); got != tt.chainCode {
+			t.Errorf("%v: chain code = %s, want %s", tt.path, got, tt.chainCode)
+		}
+
+		priv, err := DeriveKey(seed, tt.path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(priv.D.FillBytes(make([]byte, 32))); got != tt.key {
+			t.Errorf("DeriveKey(%v) = %s, want %s", tt.path, got, tt.key)
+		}
+	}
+}
+
+func TestDeriveReceiveKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	pub0, priv0, err := DeriveReceiveKey(seed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub1, priv1, err := DeriveReceiveKey(seed, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub0 == pub1 || priv0 == priv1 {
+		t.Error("receive indexes 0 and 1 derived the same key")
+	}
+	if again, _, _ := DeriveReceiveKey(seed, 0); again != pub0 {
+		t.Error("DeriveReceiveKey is not deterministic")
+	}
+	if _, _, err := DeriveReceiveKey(seed, -1); err == nil {
+		t.Error("DeriveReceiveKey(-1) succeeded")
+	}
+}
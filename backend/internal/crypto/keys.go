@@ -20,7 +20,14 @@ func PublicKeyToBytes(pub ecdsa.PublicKey) []byte {
 	return append(xBytes, yBytes...)
 }
 
+// WalletIDFromPublicKey derives the wallet id handed out at registration:
+// SHA-256 of the hex-encoded X||Y public key.
 func WalletIDFromPublicKey(pub ecdsa.PublicKey) string {
-	h := sha256.Sum256(PublicKeyToBytes(pub))
+	return WalletIDFromPublicKeyHex(hex.EncodeToString(PublicKeyToBytes(pub)))
+}
+
+// WalletIDFromPublicKeyHex is WalletIDFromPublicKey for a key already in hex.
+func WalletIDFromPublicKeyHex(pubHex string) string {
+	h := sha256.Sum256([]byte(pubHex))
 	return hex.EncodeToString(h[:])
 }
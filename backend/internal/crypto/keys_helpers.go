@@ -32,3 +32,30 @@ func PrivateFromHex(privHex string) (*ecdsa.PrivateKey, *ecdsa.PublicKey, error)
 	}
 	return priv, &priv.PublicKey, nil
 }
+
+// PublicFromHex parses a public key stored as hex of X||Y.
+// GenerateKeyPair does not left-pad the coordinates, so when the total
+// length is short we try each split and keep the one that lies on the curve.
+func PublicFromHex(pubHex string) (*ecdsa.PublicKey, error) {
+	pubBytes, err := hex.DecodeString(pubHex)
+	if err != nil {
+		return nil, err
+	}
+	curve := elliptic.P256()
+	size := (curve.Params().BitSize + 7) / 8
+	if len(pubBytes) == 0 || len(pubBytes) > 2*size {
+		return nil, errors.New("invalid public key length")
+	}
+
+	for xLen := size; xLen >= len(pubBytes)-size; xLen-- {
+		if xLen <= 0 || xLen >= len(pubBytes) {
+			continue
+		}
+		x := new(big.Int).SetBytes(pubBytes[:xLen])
+		y := new(big.Int).SetBytes(pubBytes[xLen:])
+		if curve.IsOnCurve(x, y) {
+			return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+		}
+	}
+	return nil, errors.New("public key is not on curve")
+}
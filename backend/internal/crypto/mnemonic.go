@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"crypto/pbkdf2"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordlistEnglish is the BIP-39 English wordlist
+// (sha256 2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda).
+//
+//go:embed wordlist_english.txt
+var wordlistEnglish string
+
+var (
+	mnemonicWords = strings.Fields(wordlistEnglish)
+	mnemonicIndex = func() map[string]int {
+		m := make(map[string]int, len(mnemonicWords))
+		for i, w := range mnemonicWords {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+// MnemonicEntropyBits is the entropy of a generated seed phrase: 128 bits,
+// which BIP-39 encodes as 12 words.
+const MnemonicEntropyBits = 128
+
+var ErrInvalidMnemonic = errors.New("invalid seed phrase")
+
+// NewMnemonic returns a fresh 12-word BIP-39 seed phrase.
+func NewMnemonic() (string, error) {
+	entropy := make([]byte, MnemonicEntropyBits/8)
+	if _, err := crand.Read(entropy); err != nil {
+		return "", err
+	}
+	return entropyToMnemonic(entropy), nil
+}
+
+// entropyToMnemonic appends the BIP-39 checksum (the first len/32 bits of
+// SHA-256 of the entropy) and spells the result out 11 bits per word.
+func entropyToMnemonic(entropy []byte) string {
+	csBits := len(entropy) * 8 / 32
+	sum := sha256.Sum256(entropy)
+
+	n := new(big.Int).SetBytes(entropy)
+	n.Lsh(n, uint(csBits))
+	n.Or(n, big.NewInt(int64(sum[0]>>(8-csBits))))
+
+	count := (len(entropy)*8 + csBits) / 11
+	words := make([]string, count)
+	mask := big.NewInt(2047)
+	for i := count - 1; i >= 0; i-- {
+		words[i] = mnemonicWords[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizeMnemonic lower-cases a seed phrase and collapses its whitespace.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// ValidateMnemonic checks that every word is on the wordlist, the length is
+// one BIP-39 allows (12 to 24 words in steps of 3) and the checksum matches.
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(NormalizeMnemonic(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return fmt.Errorf("%w: want 12, 15, 18, 21 or 24 words, got %d", ErrInvalidMnemonic, len(words))
+	}
+
+	n := new(big.Int)
+	for _, w := range words {
+		i, ok := mnemonicIndex[w]
+		if !ok {
+			return fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(i)))
+	}
+
+	csBits := len(words) * 11 / 33
+	checksum := new(big.Int).And(n, big.NewInt(int64(1)<<csBits-1)).Int64()
+	entropy := n.Rsh(n, uint(csBits)).FillBytes(make([]byte, csBits*32/8))
+	sum := sha256.Sum256(entropy)
+	if int64(sum[0]>>(8-csBits)) != checksum {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return nil
+}
+
+// SeedFromMnemonic validates a seed phrase and stretches it into the 64-byte
+// BIP-39 seed: PBKDF2-HMAC-SHA512, 2048 rounds, salted with "mnemonic" and
+// the optional passphrase.
+func SeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	password := norm.NFKD.String(NormalizeMnemonic(mnemonic))
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key(sha512.New, password, []byte(salt), 2048, 64)
+}
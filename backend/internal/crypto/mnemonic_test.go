@@ -0,0 +1,76 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Vectors from the BIP-39 reference implementation (passphrase "TREZOR").
+func TestMnemonicVectors(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "8080808080808080808080808080808080808080808080808080808080808080",
+			mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+		},
+	}
+	for _, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		if got := entropyToMnemonic(entropy); got != tt.mnemonic {
+			t.Errorf("entropyToMnemonic(%s) = %q, want %q", tt.entropy, got, tt.mnemonic)
+		}
+		if err := ValidateMnemonic(tt.mnemonic); err != nil {
+			t.Errorf("ValidateMnemonic(%q) = %v", tt.mnemonic, err)
+		}
+		if tt.seed == "" {
+			continue
+		}
+		seed, err := SeedFromMnemonic(tt.mnemonic, "TREZOR")
+		want, _ := hex.DecodeString(tt.seed)
+		if err != nil || !bytes.Equal(seed, want) {
+			t.Errorf("SeedFromMnemonic(%q) = %x, %v, want %s", tt.mnemonic, seed, err, tt.seed)
+		}
+	}
+}
+
+func TestValidateMnemonicRejects(t *testing.T) {
+	tests := []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", // bad checksum
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",           // 11 words
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon zzzz",    // not in the list
+	}
+	for _, m := range tests {
+		if err := ValidateMnemonic(m); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Errorf("ValidateMnemonic(%q) = %v, want ErrInvalidMnemonic", m, err)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	m, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Fields(m)); n != MnemonicEntropyBits/11+1 {
+		t.Errorf("NewMnemonic has %d words", n)
+	}
+	if err := ValidateMnemonic(m); err != nil {
+		t.Errorf("ValidateMnemonic(NewMnemonic()) = %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortPublicKeys returns lower-cased copies of hex public keys in
+// ascending order, the order multisig policies list their members in.
+func SortPublicKeys(pubKeys []string) []string {
+	sorted := make([]string, len(pubKeys))
+	for i, k := range pubKeys {
+		sorted[i] = strings.ToLower(k)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// MultisigWalletID derives the id of an M-of-N wallet: SHA-256 of its
+// threshold and sorted member public keys, so it doesn't depend on the
+// order members are given in.
+func MultisigWalletID(threshold int, pubKeys []string) string {
+	payload := fmt.Sprintf("multisig:%d:%s", threshold, strings.Join(SortPublicKeys(pubKeys), ","))
+	h := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(h[:])
+}
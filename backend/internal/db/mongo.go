@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -29,3 +31,38 @@ func ConnectMongo() error {
 func Col(name string) *mongo.Collection {
 	return DB.Collection(name)
 }
+
+// ByID builds an _id filter for an id we hold as a string.
+// Documents inserted without an explicit id get a native ObjectID, which
+// the driver decodes to its hex form, so match either representation.
+func ByID(id string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"_id": bson.M{"$in": bson.A{oid, id}}}
+	}
+	return bson.M{"_id": id}
+}
+
+// WithTransaction runs fn inside a multi-document transaction, retrying on
+// transient errors and aborting if fn returns an error.
+// Requires a replica set or sharded cluster (Atlas provides one).
+func WithTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	session, err := Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// EnsureIndexes creates the indexes the chain logic relies on.
+func EnsureIndexes(ctx context.Context) error {
+	_, err := Col("blocks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "index", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
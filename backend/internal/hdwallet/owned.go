@@ -0,0 +1,35 @@
+package hdwallet
+
+import (
+	"context"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// OwnedWallets returns the ids of every wallet user holds the key of: the
+// main wallet and each labelled address. user may be a Sender view of one
+// of its addresses.
+func OwnedWallets(ctx context.Context, user models.User) ([]string, error) {
+	cur, err := db.Col("addresses").Find(ctx, bson.M{"user_id": user.ID})
+	if err != nil {
+		return nil, err
+	}
+	var addrs []models.Address
+	if err := cur.All(ctx, &addrs); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{user.WalletID: true}
+	owned := []string{user.WalletID}
+	for _, a := range addrs {
+		for _, id := range []string{a.OwnerWallet, a.WalletID} {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				owned = append(owned, id)
+			}
+		}
+	}
+	return owned, nil
+}
@@ -0,0 +1,77 @@
+package hdwallet
+
+import (
+	"context"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Found is a receive address a scan derived, with what the UTXO set holds
+// for it.
+type Found struct {
+	Index      int           `json:"index"`
+	WalletID   string        `json:"wallet_id"`
+	PublicKey  string        `json:"public_key"`
+	PrivKeyHex string        `json:"-"`
+	Used       bool          `json:"used"`    // ever received an output
+	Balance    models.Amount `json:"balance"` // unspent outputs
+}
+
+// Scan derives seed's receive addresses in order and looks each up in the
+// UTXO set, stopping once gapLimit addresses in a row never received
+// anything. Index 0, the main wallet, is always returned; other addresses
+// only if used. next is the first index after the last used address.
+func Scan(ctx context.Context, seed []byte, gapLimit int) (found []Found, next int, err error) {
+	next = 1
+	for i, gap := 0, 0; gap < gapLimit; i++ {
+		pubHex, privHex, err := crypto.DeriveReceiveKey(seed, i)
+		if err != nil {
+			return nil, 0, err
+		}
+		f := Found{
+			Index:      i,
+			WalletID:   crypto.WalletIDFromPublicKeyHex(pubHex),
+			PublicKey:  pubHex,
+			PrivKeyHex: privHex,
+		}
+		if err := lookup(ctx, &f); err != nil {
+			return nil, 0, err
+		}
+
+		if f.Used {
+			gap = 0
+			next = i + 1
+		} else if i > 0 {
+			gap++
+		}
+		if f.Used || i == 0 {
+			found = append(found, f)
+		}
+	}
+	return found, next, nil
+}
+
+// lookup fills in whether f's wallet ever owned an output and its unspent
+// total.
+func lookup(ctx context.Context, f *Found) error {
+	cur, err := db.Col("utxos").Find(ctx, bson.M{"owner_wallet": f.WalletID})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var u models.UTXO
+		if err := cur.Decode(&u); err != nil {
+			return err
+		}
+		f.Used = true
+		if !u.IsSpent {
+			f.Balance += u.Amount
+		}
+	}
+	return cur.Err()
+}
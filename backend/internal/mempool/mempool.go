@@ -0,0 +1,274 @@
+package mempool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicate is returned when a tx with the same id is already pending.
+var ErrDuplicate = errors.New("transaction already in mempool")
+
+// ErrConflict is returned when a tx spends a UTXO another pending tx spends.
+var ErrConflict = errors.New("conflicts with a pending transaction")
+
+// Entry is a pending transaction with the data used to prioritise it.
+type Entry struct {
+	Tx      models.Transaction `json:"-"`
+	TxID    string             `json:"tx_id"`
+	Type    string             `json:"type"`
+	Sender  string             `json:"sender_wallet"`
+	Amount  models.Amount      `json:"amount"`
+	Fee     models.Amount      `json:"fee"`      // inputs minus outputs, paid to the miner
+	Size    int                `json:"size"`     // bytes, see blockchain.TxSize
+	FeeRate models.Amount      `json:"fee_rate"` // fee per 1000 bytes
+	AddedAt time.Time          `json:"added_at"`
+}
+
+// The pool is an in-process index over the pending_transactions
+// collection, which stays the durable copy and is reloaded on startup.
+var (
+	mu      sync.RWMutex
+	entries = map[string]*Entry{}
+	spends  = map[string]string{} // utxo id -> id of the pending tx spending it
+)
+
+func newEntry(tx models.Transaction, fee models.Amount) *Entry {
+	size := blockchain.TxSize(tx)
+	e := &Entry{
+		Tx:      tx,
+		TxID:    tx.ID,
+		Type:    tx.Type,
+		Sender:  tx.SenderWallet,
+		Amount:  tx.Amount,
+		Fee:     fee,
+		Size:    size,
+		AddedAt: time.Now().UTC(),
+	}
+	if size > 0 {
+		e.FeeRate = fee * 1000 / models.Amount(size)
+	}
+	return e
+}
+
+// Add stores a validated tx paying fee in the pool and the
+// pending_transactions collection and reserves its inputs until it is
+// mined or evicted. Txs spending a UTXO that another pending tx already
+// spends are refused (first seen wins).
+func Add(ctx context.Context, tx models.Transaction, fee models.Amount) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := entries[tx.ID]; ok {
+		return ErrDuplicate
+	}
+	for _, in := range tx.Inputs {
+		if other, ok := spends[in.UTXOId]; ok {
+			return fmt.Errorf("%w: utxo %s is spent by %s", ErrConflict, in.UTXOId, other)
+		}
+	}
+
+	err := db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := db.Col("pending_transactions").InsertOne(sc, tx); err != nil {
+			return err
+		}
+		return utxo.Reserve(sc, tx.ID, inputIDs(tx), reservedUntil(tx))
+	})
+	if errors.Is(err, utxo.ErrReserved) {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	if err != nil {
+		return err
+	}
+	index(newEntry(tx, fee))
+	return nil
+}
+
+func inputIDs(tx models.Transaction) []string {
+	ids := make([]string, 0, len(tx.Inputs))
+	for _, in := range tx.Inputs {
+		ids = append(ids, in.UTXOId)
+	}
+	return ids
+}
+
+// reservedUntil is when tx's hold on its inputs lapses: when it would be
+// evicted from the pool.
+func reservedUntil(tx models.Transaction) time.Time {
+	return tx.Timestamp.Add(config.AppConfig.MempoolMaxAge)
+}
+
+// index must be called with mu held.
+func index(e *Entry) {
+	entries[e.TxID] = e
+	for _, in := range e.Tx.Inputs {
+		if _, taken := spends[in.UTXOId]; !taken {
+			spends[in.UTXOId] = e.TxID
+		}
+	}
+}
+
+// Remove drops txs from the in-memory pool only, for when their documents
+// were already deleted, e.g. by the block that confirmed them.
+func Remove(ids ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range ids {
+		e, ok := entries[id]
+		if !ok {
+			continue
+		}
+		for _, in := range e.Tx.Inputs {
+			if spends[in.UTXOId] == id {
+				delete(spends, in.UTXOId)
+			}
+		}
+		delete(entries, id)
+	}
+}
+
+// Reject drops a tx that can never be mined from the pool, releases its
+// inputs and records it, with the reason, as rejected in the transactions
+// collection. If that fails the tx stays pooled so a later call can retry.
+func Reject(ctx context.Context, tx models.Transaction, reason string) error {
+	tx.Status = "rejected"
+	tx.RejectReason = reason
+	err := db.WithTransaction(ctx, func(sc mongo.SessionContext) error {
+		if _, err := db.Col("transactions").ReplaceOne(sc, bson.M{"_id": tx.ID}, tx, options.Replace().SetUpsert(true)); err != nil {
+			return err
+		}
+		if _, err := db.Col("pending_transactions").DeleteOne(sc, db.ByID(tx.ID)); err != nil {
+			return err
+		}
+		return utxo.Release(sc, tx.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("reject %s: %w", tx.ID, err)
+	}
+	Remove(tx.ID)
+	return nil
+}
+
+// Snapshot returns the pool ordered for block inclusion: highest fee rate
+// first, older txs first on ties.
+func Snapshot() []Entry {
+	mu.RLock()
+	out := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, *e)
+	}
+	mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FeeRate != out[j].FeeRate {
+			return out[i].FeeRate > out[j].FeeRate
+		}
+		return out[i].Tx.Timestamp.Before(out[j].Tx.Timestamp)
+	})
+	return out
+}
+
+// EstimateFeeRate suggests a fee per 1000 bytes for a tx to make the next
+// block. While the pool fits in one block that is the configured base
+// rate; otherwise it outbids the best tx that would be left out.
+func EstimateFeeRate() models.Amount {
+	rate := config.AppConfig.BaseFeeRate
+	txs, bytes := 0, 0
+	for _, e := range Snapshot() {
+		txs++
+		bytes += e.Size
+		if txs > config.AppConfig.MaxBlockTxs || bytes > config.AppConfig.MaxBlockBytes {
+			if bid := e.FeeRate + e.FeeRate/10 + 1; bid > rate {
+				rate = bid
+			}
+			break
+		}
+	}
+	return rate
+}
+
+// Load rebuilds the pool from the pending_transactions collection and
+// re-reserves inputs for txs stored before reservations existed. Docs
+// whose inputs are spent or held elsewhere are still indexed so mining
+// can reject them with a reason.
+func Load(ctx context.Context) error {
+	cur, err := db.Col("pending_transactions").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	entries = map[string]*Entry{}
+	spends = map[string]string{}
+	for cur.Next(ctx) {
+		var t models.Transaction
+		if err := cur.Decode(&t); err != nil {
+			continue
+		}
+		err := utxo.Reserve(ctx, t.ID, inputIDs(t), reservedUntil(t))
+		if errors.Is(err, utxo.ErrReserved) {
+			// spent or held by another tx: mining will reject it
+			log.Printf("mempool: load %s: %v", t.ID, err)
+		} else if err != nil {
+			return fmt.Errorf("reserve inputs of %s: %w", t.ID, err)
+		}
+		index(newEntry(t, t.Fee)) // validated on entry to equal inputs minus outputs
+	}
+	return cur.Err()
+}
+
+// EvictStale rejects pending txs created more than maxAge ago and returns
+// how many were evicted.
+func EvictStale(ctx context.Context, maxAge time.Duration) int {
+	cutoff := time.Now().Add(-maxAge)
+
+	var stale []models.Transaction
+	mu.RLock()
+	for _, e := range entries {
+		if e.Tx.Timestamp.Before(cutoff) {
+			stale = append(stale, e.Tx)
+		}
+	}
+	mu.RUnlock()
+
+	n := 0
+	for _, t := range stale {
+		if err := Reject(ctx, t, fmt.Sprintf("evicted from mempool after %s", maxAge)); err != nil {
+			log.Printf("mempool: %v", err)
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// RunEvictor calls EvictStale every interval until ctx is done.
+func RunEvictor(ctx context.Context, interval, maxAge time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := EvictStale(ctx, maxAge); n > 0 {
+				log.Printf("mempool: evicted %d stale transactions", n)
+			}
+		}
+	}
+}
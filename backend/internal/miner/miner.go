@@ -0,0 +1,208 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrBusy is returned by Start while another mining job is running.
+var ErrBusy = errors.New("a mining job is already running")
+
+// Result describes how a mining job ended.
+type Result struct {
+	JobID          string        `json:"job_id"`
+	BlockIndex     int           `json:"block_index"`
+	BlockHash      string        `json:"block_hash,omitempty"`
+	MinerWallet    string        `json:"miner_wallet"`
+	RewardAmount   models.Amount `json:"reward_amount"` // subsidy plus fees
+	FeesCollected  models.Amount `json:"fees_collected"`
+	TxInBlock      int           `json:"tx_in_block"`
+	UserTxMined    int           `json:"user_tx_mined"`
+	UserTxRejected int           `json:"user_tx_rejected"`
+	UserTxSkipped  int           `json:"user_tx_skipped"`
+	Attempts       uint64        `json:"attempts"`
+	Error          string        `json:"error,omitempty"`
+	FinishedAt     time.Time     `json:"finished_at"`
+}
+
+// Status is a snapshot of the miner for GET /api/admin/mining/status.
+type Status struct {
+	Running     bool       `json:"running"`
+	JobID       string     `json:"job_id,omitempty"`
+	MinerWallet string     `json:"miner_wallet,omitempty"`
+	BlockIndex  int        `json:"block_index,omitempty"`
+	Bits        string     `json:"bits,omitempty"`
+	Workers     int        `json:"workers,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Attempts    uint64     `json:"attempts"`
+	HashRate    float64    `json:"hash_rate"` // hashes per second
+	LastResult  *Result    `json:"last_result,omitempty"`
+}
+
+// Job is a running or finished mining job.
+type Job struct {
+	id          string
+	minerWallet string
+	workers     int
+	startedAt   time.Time
+	cancel      context.CancelFunc
+	done        chan struct{}
+	attempts    atomic.Uint64
+
+	// set once the template is built, guarded by mu
+	blockIndex int
+	bits       uint32
+
+	result *Result // set before done is closed
+}
+
+// Done is closed when the job finishes.
+func (j *Job) Done() <-chan struct{} { return j.done }
+
+// Result is only valid after Done is closed.
+func (j *Job) Result() *Result { return j.result }
+
+var (
+	mu         sync.Mutex
+	current    *Job
+	lastResult *Result
+)
+
+// Start launches a background job that mines one block for minerWalletID
+// across GOMAXPROCS workers. Only one job runs at a time.
+func Start(minerWalletID string) (*Job, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil {
+		return nil, ErrBusy
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.MiningTimeout)
+	j := &Job{
+		id:          primitive.NewObjectID().Hex(),
+		minerWallet: minerWalletID,
+		workers:     runtime.GOMAXPROCS(0),
+		startedAt:   time.Now().UTC(),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	current = j
+	go j.run(ctx)
+	return j, nil
+}
+
+// Stop cancels the running job, if any, and reports whether there was one.
+func Stop() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return false
+	}
+	current.cancel()
+	return true
+}
+
+// CurrentStatus reports the running job's progress and the last result.
+func CurrentStatus() Status {
+	mu.Lock()
+	defer mu.Unlock()
+
+	st := Status{LastResult: lastResult}
+	if current == nil {
+		return st
+	}
+	j := current
+	started := j.startedAt
+	st.Running = true
+	st.JobID = j.id
+	st.MinerWallet = j.minerWallet
+	st.BlockIndex = j.blockIndex
+	if j.bits != 0 {
+		st.Bits = fmt.Sprintf("%08x", j.bits)
+	}
+	st.Workers = j.workers
+	st.StartedAt = &started
+	st.Attempts = j.attempts.Load()
+	if elapsed := time.Since(started).Seconds(); elapsed > 0 {
+		st.HashRate = float64(st.Attempts) / elapsed
+	}
+	return st
+}
+
+func (j *Job) run(ctx context.Context) {
+	defer j.cancel()
+
+	res := &Result{JobID: j.id, MinerWallet: j.minerWallet}
+	if err := j.mine(ctx, res); err != nil {
+		res.Error = err.Error()
+		logger.AddSystemEvent("mine_failed", fmt.Sprintf("job=%s block #%d error=%v", j.id, res.BlockIndex, err))
+	}
+	res.Attempts = j.attempts.Load()
+	res.FinishedAt = time.Now().UTC()
+
+	mu.Lock()
+	j.result = res
+	lastResult = res
+	current = nil
+	mu.Unlock()
+	close(j.done)
+}
+
+func (j *Job) mine(ctx context.Context, res *Result) error {
+	tpl, err := buildTemplate(ctx, j.minerWallet)
+	if err != nil {
+		return err
+	}
+	block := tpl.block
+
+	mu.Lock()
+	j.blockIndex = block.Index
+	j.bits = block.Bits
+	mu.Unlock()
+
+	res.BlockIndex = block.Index
+	res.RewardAmount = tpl.block.Transactions[0].Amount
+	res.FeesCollected = tpl.fees
+	res.TxInBlock = len(block.Transactions)
+	res.UserTxRejected = tpl.rejected
+	res.UserTxSkipped = tpl.pendingSeen - len(tpl.userTxIDs) - tpl.rejected
+
+	if err := blockchain.MineBlockContext(ctx, &block, j.workers, &j.attempts); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.New("mining timed out")
+		}
+		return errors.New("mining stopped")
+	}
+
+	// --- insert block and apply its effects atomically ---
+	if err := blockchain.ApplyBlock(context.Background(), &block); err != nil {
+		return err
+	}
+	mempool.Remove(tpl.userTxIDs...)
+	res.BlockHash = block.Hash
+	res.UserTxMined = len(tpl.userTxIDs)
+
+	logger.AddSystemEvent(
+		"mined_block",
+		fmt.Sprintf("Block #%d mined by %s with reward %s (fees %s), user_tx=%d",
+			block.Index,
+			j.minerWallet,
+			res.RewardAmount,
+			tpl.fees,
+			len(tpl.userTxIDs),
+		),
+	)
+	return nil
+}
@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// template is a block ready for proof-of-work plus what went into it.
+type template struct {
+	block       models.Block
+	fees        models.Amount
+	pendingSeen int
+	userTxIDs   []string
+	rejected    int
+}
+
+// buildTemplate assembles the next block on top of the current tip:
+// a coinbase paying minerWalletID the block subsidy plus fees, maturing
+// after config.CoinbaseMaturity blocks, then pending txs by descending fee
+// rate up to the block's tx count and byte limits.
+// Txs failing validation are moved to the rejected state; txs skipped for
+// transient errors, time locks or lack of space stay in the mempool.
+func buildTemplate(ctx context.Context, minerWalletID string) (*template, error) {
+	// --- get last block (for index + prev hash) ---
+	var last models.Block
+	err := db.Col("blocks").FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"index": -1})).Decode(&last)
+	hasPrev := err == nil
+	nextIndex := 0
+	if hasPrev {
+		nextIndex = last.Index + 1
+	}
+	// timestamp is truncated to what Mongo stores so the hash can be recomputed later
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	// --- take pending txs by fee rate, revalidating each one ---
+	candidates := mempool.Snapshot()
+	claimed := map[string]bool{}
+	var includedTxs []models.Transaction
+	var fees models.Amount
+	blockBytes, rejected := 0, 0
+	for _, e := range candidates {
+		if len(includedTxs) >= config.AppConfig.MaxBlockTxs {
+			break
+		}
+		if blockBytes+e.Size > config.AppConfig.MaxBlockBytes {
+			continue
+		}
+		_, err := blockchain.ValidateTransaction(ctx, e.Tx, nextIndex, now, claimed)
+		if err != nil {
+			if errors.Is(err, blockchain.ErrInvalidTx) {
+				if err := mempool.Reject(ctx, e.Tx, err.Error()); err != nil {
+					return nil, err
+				}
+				rejected++
+			}
+			continue
+		}
+		fees += e.Tx.Fee // validated to equal inputs minus outputs
+		blockBytes += e.Size
+		includedTxs = append(includedTxs, e.Tx)
+	}
+
+	// --- create coinbase (mining reward + fees) transaction ---
+	reward := blockchain.Subsidy(nextIndex) + fees
+	coinbaseTx := models.Transaction{
+		SenderWallet:   "SYSTEM_COINBASE",
+		ReceiverWallet: minerWalletID,
+		Amount:         reward,
+		Note:           fmt.Sprintf("Mining reward for block #%d", nextIndex), // height keeps coinbase ids unique
+		Outputs: []models.TxUTXOOutput{
+			{
+				OwnerWallet:    minerWalletID,
+				Amount:         reward,
+				MaturityHeight: nextIndex + config.AppConfig.CoinbaseMaturity,
+			},
+		},
+		Type:      "mining_reward",
+		Status:    "confirmed",
+		Timestamp: now,
+	}
+	blockchain.SealTx(&coinbaseTx)
+
+	// --- assemble all block transactions: reward + pending ---
+	allTxs := make([]models.Transaction, 0, len(includedTxs)+1)
+	allTxs = append(allTxs, coinbaseTx)
+	allTxs = append(allTxs, includedTxs...)
+
+	// --- build block ---
+	block := models.Block{
+		Version:      blockchain.BlockVersionTimeLocks,
+		Index:        nextIndex,
+		Timestamp:    now,
+		Transactions: allTxs,
+		PreviousHash: "",
+	}
+	var prevBlock *models.Block
+	if hasPrev {
+		block.PreviousHash = last.Hash
+		prevBlock = &last
+	}
+	block.Bits, err = blockchain.NextBits(ctx, prevBlock)
+	if err != nil {
+		return nil, err
+	}
+	block.MerkleRoot = blockchain.MerkleRoot(block.Transactions)
+
+	tpl := &template{
+		block:       block,
+		fees:        fees,
+		pendingSeen: len(candidates),
+		rejected:    rejected,
+	}
+	for _, t := range includedTxs {
+		tpl.userTxIDs = append(tpl.userTxIDs, t.ID)
+	}
+	return tpl, nil
+}
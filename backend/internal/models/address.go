@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// MainAddressLabel names a user's main wallet among their addresses.
+const MainAddressLabel = "main"
+
+// Address is an extra labelled address of a user with its own key. For a
+// wallet with a seed phrase the key is derived at Index; Index 0 is the
+// main wallet itself, so an Address with Index 0 holds a random key of a
+// wallet created before seed phrases.
+type Address struct {
+	ID               string    `bson:"_id,omitempty" json:"id"`
+	UserID           string    `bson:"user_id" json:"user_id"`
+	OwnerWallet      string    `bson:"owner_wallet" json:"owner_wallet"` // the user's main wallet id
+	WalletID         string    `bson:"wallet_id" json:"wallet_id"`
+	Label            string    `bson:"label" json:"label"` // unique per user, e.g. savings or donations
+	Index            int       `bson:"index" json:"index"`
+	PublicKey        string    `bson:"public_key" json:"public_key"`
+	EncryptedPrivKey string    `bson:"encrypted_priv_key" json:"-"`
+	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
+}
+
+// MainAddress describes a user's main wallet as an Address.
+func MainAddress(u User) Address {
+	return Address{
+		UserID:           u.ID,
+		OwnerWallet:      u.WalletID,
+		WalletID:         u.WalletID,
+		Label:            MainAddressLabel,
+		PublicKey:        u.PublicKey,
+		EncryptedPrivKey: u.EncryptedPrivKey,
+		CreatedAt:        u.CreatedAt,
+	}
+}
+
+// Sender returns owner as the sender of a payment from a: owner's
+// settings with a's wallet id and key.
+func (a Address) Sender(owner User) User {
+	owner.WalletID = a.WalletID
+	owner.PublicKey = a.PublicKey
+	owner.EncryptedPrivKey = a.EncryptedPrivKey
+	return owner
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Amount is a coin value in base units; one coin is 100,000,000 units.
+// It is stored in Mongo as an int64 and shown in the API as a decimal
+// string with 8 fractional digits, e.g. "12.50000000".
+type Amount int64
+
+// Coin is one whole coin in base units.
+const Coin Amount = 100_000_000
+
+const amountDecimals = 8
+
+// String formats a as a decimal with exactly 8 fractional digits.
+func (a Amount) String() string {
+	sign := ""
+	u := uint64(a)
+	if a < 0 {
+		sign = "-"
+		u = uint64(-a)
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, u/uint64(Coin), u%uint64(Coin))
+}
+
+// Coins returns a as a float, for display math and legacy formats only.
+func (a Amount) Coins() float64 {
+	return float64(a) / float64(Coin)
+}
+
+// ParseAmount reads a decimal coin value such as "12.5" or "0.00000001".
+// More than 8 fractional digits is an error rather than silent rounding.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > amountDecimals {
+		return 0, fmt.Errorf("amount %q has more than %d decimals", s, amountDecimals)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", amountDecimals-len(frac))
+
+	w, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	f, err := strconv.ParseUint(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if w > (math.MaxInt64-f)/uint64(Coin) {
+		return 0, fmt.Errorf("amount %q out of range", s)
+	}
+
+	a := Amount(w)*Coin + Amount(f)
+	if neg {
+		a = -a
+	}
+	return a, nil
+}
+
+// ErrAmountOverflow is returned when a sum of amounts leaves the range
+// that can exist.
+var ErrAmountOverflow = errors.New("amount out of range")
+
+// AddAmounts returns a+b for non-negative amounts, failing with
+// ErrAmountOverflow instead of wrapping when the sum passes limit or
+// math.MaxInt64.
+func AddAmounts(a, b, limit Amount) (Amount, error) {
+	if a < 0 || b < 0 {
+		return 0, fmt.Errorf("%w: negative amount in sum", ErrAmountOverflow)
+	}
+	if a > math.MaxInt64-b || a+b > limit {
+		return 0, fmt.Errorf("%w: %s + %s exceeds %s", ErrAmountOverflow, a, b, limit)
+	}
+	return a + b, nil
+}
+
+// AmountFromCoins converts a legacy float coin value, rounding to the
+// nearest base unit.
+func AmountFromCoins(f float64) Amount {
+	return Amount(math.Round(f * float64(Coin)))
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts the decimal string form and, for older clients,
+// a bare JSON number, which is read from its literal digits.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if unq, err := strconv.Unquote(s); err == nil {
+		s = unq
+	} else if strings.ContainsAny(s, "eE") {
+		// exponent notation from JS number serialisation
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*a = AmountFromCoins(f)
+		return nil
+	}
+	v, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// UnmarshalParam lets gin bind query and form values.
+func (a *Amount) UnmarshalParam(param string) error {
+	v, err := ParseAmount(param)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// UnmarshalBSONValue reads base units, and also float coin values from
+// documents written before amounts were fixed-point (see cmd/admin
+// migrate-amounts).
+func (a *Amount) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Int64:
+		v, _, ok := bsoncore.ReadInt64(data)
+		if !ok {
+			return errors.New("amount: truncated int64")
+		}
+		*a = Amount(v)
+	case bsontype.Int32:
+		v, _, ok := bsoncore.ReadInt32(data)
+		if !ok {
+			return errors.New("amount: truncated int32")
+		}
+		*a = Amount(v)
+	case bsontype.Double:
+		v, _, ok := bsoncore.ReadDouble(data)
+		if !ok {
+			return errors.New("amount: truncated double")
+		}
+		*a = AmountFromCoins(v)
+	case bsontype.Null, bsontype.Undefined:
+		*a = 0
+	default:
+		return fmt.Errorf("amount: cannot decode bson %s", t)
+	}
+	return nil
+}
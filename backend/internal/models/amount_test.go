@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "12.5", want: 12*Coin + Coin/2},
+		{in: "0.00000001", want: 1},
+		{in: ".5", want: Coin / 2},
+		{in: "-1.5", want: -(Coin + Coin/2)},
+		{in: "92233720368.54775807", want: math.MaxInt64},
+		{in: "92233720368.54775808", wantErr: true},
+		{in: "92233720368.99999999", wantErr: true},
+		{in: "92233720369", wantErr: true},
+		{in: "1.000000001", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1e5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAmount(%q) = %d, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestAddAmounts(t *testing.T) {
+	if got, err := AddAmounts(2*Coin, 3*Coin, 10*Coin); err != nil || got != 5*Coin {
+		t.Fatalf("AddAmounts = %s, %v, want 5", got, err)
+	}
+	tests := []struct {
+		name     string
+		a, b     Amount
+		limit    Amount
+		wantFail bool
+	}{
+		{"at limit", 4 * Coin, 6 * Coin, 10 * Coin, false},
+		{"above limit", 4 * Coin, 7 * Coin, 10 * Coin, true},
+		{"wraps int64", math.MaxInt64, 1, math.MaxInt64, true},
+		{"huge pair", 1 << 62, 1 << 62, math.MaxInt64, true},
+		{"negative", -1, 5, 10, true},
+	}
+	for _, tt := range tests {
+		_, err := AddAmounts(tt.a, tt.b, tt.limit)
+		if (err != nil) != tt.wantFail {
+			t.Errorf("%s: err = %v, want failure %v", tt.name, err, tt.wantFail)
+		}
+		if err != nil && !errors.Is(err, ErrAmountOverflow) {
+			t.Errorf("%s: err = %v, want ErrAmountOverflow", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Beneficiary is a saved contact a user can pay by nickname.
+type Beneficiary struct {
+	Name          string    `bson:"name" json:"name"` // nickname, unique per user
+	WalletID      string    `bson:"wallet_id" json:"wallet_id"`
+	Label         string    `bson:"label,omitempty" json:"label,omitempty"` // e.g. "family", "rent"
+	DefaultAmount Amount    `bson:"default_amount,omitempty" json:"default_amount,omitempty"`
+	AddedAt       time.Time `bson:"added_at" json:"added_at"`
+}
+
+// beneficiaryFields has Beneficiary's fields without its decoding methods.
+type beneficiaryFields Beneficiary
+
+// UnmarshalBSONValue also accepts the bare wallet id strings beneficiaries
+// were stored as before they had names.
+func (b *Beneficiary) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bsontype.String {
+		s, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return errors.New("beneficiary: truncated string")
+		}
+		*b = Beneficiary{Name: s, WalletID: s}
+		return nil
+	}
+	if t != bsontype.EmbeddedDocument {
+		return fmt.Errorf("beneficiary: cannot decode bson %s", t)
+	}
+	return bson.Unmarshal(data, (*beneficiaryFields)(b))
+}
+
+// UnmarshalJSON accepts a contact object or, for older clients, a bare
+// wallet id.
+func (b *Beneficiary) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Beneficiary{WalletID: s}
+		return nil
+	}
+	return json.Unmarshal(data, (*beneficiaryFields)(b))
+}
+
+// ErrBeneficiaryOnly is returned when a transfer breaks the user's
+// beneficiaries-only setting.
+var ErrBeneficiaryOnly = errors.New("transfers above the limit may only go to beneficiaries")
+
+// FindBeneficiary looks up a contact by nickname, ignoring case.
+func (u User) FindBeneficiary(name string) (Beneficiary, bool) {
+	for _, b := range u.Beneficiaries {
+		if strings.EqualFold(b.Name, name) {
+			return b, true
+		}
+	}
+	return Beneficiary{}, false
+}
+
+// IsBeneficiary reports whether walletID is one of u's contacts.
+func (u User) IsBeneficiary(walletID string) bool {
+	for _, b := range u.Beneficiaries {
+		if b.WalletID == walletID {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOutgoing applies u's anti-fraud setting to a payment of amount to
+// receiver: with BeneficiaryOnly on, anything above BeneficiaryOnlyAbove
+// must go to a beneficiary or to one of owned, the wallets u holds.
+func (u User) CheckOutgoing(receiver string, amount Amount, owned []string) error {
+	if !u.BeneficiaryOnly || amount <= u.BeneficiaryOnlyAbove {
+		return nil
+	}
+	if receiver == u.WalletID || slices.Contains(owned, receiver) || u.IsBeneficiary(receiver) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s to %s exceeds %s", ErrBeneficiaryOnly, amount, receiver, u.BeneficiaryOnlyAbove)
+}
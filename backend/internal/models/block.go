@@ -4,11 +4,23 @@ import "time"
 
 type Block struct {
 	ID           string        `bson:"_id,omitempty" json:"id"`
+	Version      int           `bson:"version,omitempty" json:"version,omitempty"` // 0 = legacy merkle leaves, 1 = canonical tx hashes, 2 = + compact target bits
 	Index        int           `bson:"index" json:"index"`
 	Timestamp    time.Time     `bson:"timestamp" json:"timestamp"`
 	Transactions []Transaction `bson:"transactions" json:"transactions"`
 	PreviousHash string        `bson:"previous_hash" json:"previous_hash"`
 	Nonce        int64         `bson:"nonce" json:"nonce"`
+	Bits         uint32        `bson:"bits,omitempty" json:"bits,omitempty"` // compact 256-bit PoW target
 	Hash         string        `bson:"hash" json:"hash"`
 	MerkleRoot   string        `bson:"merkle_root,omitempty" json:"merkle_root,omitempty"`
+
+	// ApplyState tracks ApplyBlock: "applying" from the commit that stores
+	// the block until the one that writes its effects, then "applied". Not
+	// part of the header hash; empty for blocks written before it existed.
+	ApplyState string `bson:"apply_state,omitempty" json:"-"`
 }
+
+const (
+	BlockApplying = "applying"
+	BlockApplied  = "applied"
+)
@@ -0,0 +1,15 @@
+package models
+
+// LockHTLC is the OutputLock type of a hash-time-locked output.
+const LockHTLC = "htlc"
+
+// OutputLock is a spending condition on an output. For an HTLC the owner
+// (Recipient) may claim it with a preimage of HashLock before block
+// Deadline; from Deadline on only Refund may take it back.
+type OutputLock struct {
+	Type      string `bson:"type" json:"type"`           // htlc
+	HashLock  string `bson:"hash_lock" json:"hash_lock"` // hex SHA-256 of the preimage
+	Recipient string `bson:"recipient" json:"recipient"`
+	Refund    string `bson:"refund" json:"refund"`
+	Deadline  int    `bson:"deadline" json:"deadline"` // block height
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// MultisigPolicy is an M-of-N spending rule: Threshold of the member keys
+// must sign.
+type MultisigPolicy struct {
+	Threshold  int      `bson:"threshold" json:"threshold"`
+	PublicKeys []string `bson:"public_keys" json:"public_keys"` // hex X||Y, sorted
+}
+
+// IndexOf returns the position of pubKey among the members, or -1.
+func (p MultisigPolicy) IndexOf(pubKey string) int {
+	for i, k := range p.PublicKeys {
+		if k == pubKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// MultisigWallet is a shared wallet whose id derives from its policy
+// (see crypto.MultisigWalletID).
+type MultisigWallet struct {
+	ID             string `bson:"_id,omitempty" json:"id"`
+	WalletID       string `bson:"wallet_id" json:"wallet_id"`
+	Name           string `bson:"name" json:"name"`
+	MultisigPolicy `bson:",inline"`
+	Members        []string  `bson:"members" json:"members"` // member wallet ids, in PublicKeys order
+	CreatedBy      string    `bson:"created_by" json:"created_by"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// MultisigSpend is carried by a tx spending from a multisig wallet in
+// place of SenderPubKey and Signature.
+type MultisigSpend struct {
+	MultisigPolicy `bson:",inline"`
+	Signatures     []string `bson:"signatures" json:"signatures"` // by PublicKeys index, "" until that member signs
+}
+
+// SignatureCount is how many members have signed.
+func (m MultisigSpend) SignatureCount() int {
+	n := 0
+	for _, s := range m.Signatures {
+		if s != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// PartialTx is a multisig spend collecting its members' signatures before
+// it can be submitted.
+type PartialTx struct {
+	ID        string      `bson:"_id" json:"id"` // tx id, stable while signatures are added
+	WalletID  string      `bson:"wallet_id" json:"wallet_id"`
+	Tx        Transaction `bson:"tx" json:"tx"`
+	Status    string      `bson:"status" json:"status"` // open, finalized
+	CreatedBy string      `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time   `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time   `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecurringTransfer is a payment a user has scheduled to repeat.
+type RecurringTransfer struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerWallet    string             `bson:"owner_wallet" json:"owner_wallet"`
+	ReceiverWallet string             `bson:"receiver_wallet" json:"receiver_wallet"`
+	Amount         Amount             `bson:"amount" json:"amount"`
+	Fee            *Amount            `bson:"fee,omitempty" json:"fee,omitempty"` // estimated per run when unset
+	Note           string             `bson:"note" json:"note"`
+	Schedule       string             `bson:"schedule" json:"schedule"` // cron expression or @daily, @weekly, @monthly
+	EndAt          *time.Time         `bson:"end_at,omitempty" json:"end_at,omitempty"`
+	NextRunAt      time.Time          `bson:"next_run_at" json:"next_run_at"`
+	LastRunAt      *time.Time         `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	Status         string             `bson:"status" json:"status"` // active, paused, cancelled, completed
+	RunCount       int                `bson:"run_count" json:"run_count"`
+	SkipCount      int                `bson:"skip_count" json:"skip_count"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// RecurringRun records one due run of a recurring transfer.
+type RecurringRun struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ScheduleID primitive.ObjectID `bson:"schedule_id" json:"schedule_id"`
+	DueAt      time.Time          `bson:"due_at" json:"due_at"`
+	RanAt      time.Time          `bson:"ran_at" json:"ran_at"`
+	Status     string             `bson:"status" json:"status"` // submitted, skipped
+	TxID       string             `bson:"tx_id,omitempty" json:"tx_id,omitempty"`
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+}
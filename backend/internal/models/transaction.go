@@ -8,22 +8,30 @@ type TxUTXOInput struct {
 }
 
 type TxUTXOOutput struct {
-	OwnerWallet string  `bson:"owner_wallet" json:"owner_wallet"`
-	Amount      float64 `bson:"amount" json:"amount"`
+	OwnerWallet    string      `bson:"owner_wallet" json:"owner_wallet"`
+	Amount         Amount      `bson:"amount" json:"amount"`
+	MaturityHeight int         `bson:"maturity_height,omitempty" json:"maturity_height,omitempty"` // first block height that may spend it
+	Lock           *OutputLock `bson:"lock,omitempty" json:"lock,omitempty"`                       // extra spending condition, e.g. an HTLC
 }
 
 type Transaction struct {
 	ID             string         `bson:"_id,omitempty" json:"id"`
+	Version        int            `bson:"version,omitempty" json:"version,omitempty"` // 0 = legacy signing payload, 1 = canonical encoding
 	SenderWallet   string         `bson:"sender_wallet" json:"sender_wallet"`
 	ReceiverWallet string         `bson:"receiver_wallet" json:"receiver_wallet"`
-	Amount         float64        `bson:"amount" json:"amount"`
+	Amount         Amount         `bson:"amount" json:"amount"`
 	Note           string         `bson:"note" json:"note"`
 	Timestamp      time.Time      `bson:"timestamp" json:"timestamp"`
 	SenderPubKey   string         `bson:"sender_public_key" json:"sender_public_key"`
 	Signature      string         `bson:"signature" json:"signature"`
+	Multisig       *MultisigSpend `bson:"multisig,omitempty" json:"multisig,omitempty"` // set instead of SenderPubKey and Signature
 	Inputs         []TxUTXOInput  `bson:"inputs" json:"inputs"`
 	Outputs        []TxUTXOOutput `bson:"outputs" json:"outputs"`
-	Type           string         `bson:"type" json:"type"` // normal, zakat_deduction, mining_reward
+	Fee            Amount         `bson:"fee,omitempty" json:"fee,omitempty"`             // inputs minus outputs, paid to the miner
+	Type           string         `bson:"type" json:"type"`                               // normal, batch, htlc, htlc_claim, htlc_refund, zakat_deduction, mining_reward
+	Preimage       string         `bson:"preimage,omitempty" json:"preimage,omitempty"`   // hex, reveals the secret of the HTLCs an htlc_claim spends
+	LockTime       int64          `bson:"lock_time,omitempty" json:"lock_time,omitempty"` // earliest block height, or unix time from 500000000 on
 	BlockID        string         `bson:"block_id,omitempty" json:"block_id,omitempty"`
 	Status         string         `bson:"status" json:"status"` // pending, confirmed, rejected
+	RejectReason   string         `bson:"reject_reason,omitempty" json:"reject_reason,omitempty"`
 }
@@ -3,19 +3,28 @@ package models
 import "time"
 
 type User struct {
-	ID               string    `bson:"_id,omitempty" json:"id"`
-	FullName         string    `bson:"full_name" json:"full_name"`
-	Email            string    `bson:"email" json:"email"`
-	PasswordHash     string    `bson:"password_hash" json:"-"` // for login (optional with OTP)
-	CNIC             string    `bson:"cnic" json:"cnic"`
-	WalletID         string    `bson:"wallet_id" json:"wallet_id"`
-	PublicKey        string    `bson:"public_key" json:"public_key"`
-	EncryptedPrivKey string    `bson:"encrypted_priv_key" json:"-"`
-	Beneficiaries    []string  `bson:"beneficiaries" json:"beneficiaries"`
-	ZakatDeducted    float64   `bson:"zakat_deducted" json:"zakat_deducted"`
-	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
-	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
+	ID               string        `bson:"_id,omitempty" json:"id"`
+	FullName         string        `bson:"full_name" json:"full_name"`
+	Email            string        `bson:"email" json:"email"`
+	PasswordHash     string        `bson:"password_hash" json:"-"` // for login (optional with OTP)
+	CNIC             string        `bson:"cnic" json:"cnic"`
+	WalletID         string        `bson:"wallet_id" json:"wallet_id"`
+	PublicKey        string        `bson:"public_key" json:"public_key"`
+	EncryptedPrivKey string        `bson:"encrypted_priv_key" json:"-"`
+	Beneficiaries    []Beneficiary `bson:"beneficiaries" json:"beneficiaries"`
+	ZakatDeducted    Amount        `bson:"zakat_deducted" json:"zakat_deducted"`
+	CreatedAt        time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time     `bson:"updated_at" json:"updated_at"`
 
 	// ✅ new field for OTP-based signup
 	EmailVerified bool `bson:"email_verified" json:"email_verified"`
+
+	// anti-fraud: outgoing payments above BeneficiaryOnlyAbove may only go to beneficiaries
+	BeneficiaryOnly      bool   `bson:"beneficiary_only" json:"beneficiary_only"`
+	BeneficiaryOnlyAbove Amount `bson:"beneficiary_only_above" json:"beneficiary_only_above"`
+
+	// BIP-39 seed the wallet key and receive addresses are derived from;
+	// empty for wallets created before seed phrases
+	EncryptedSeed    string `bson:"encrypted_seed,omitempty" json:"-"`
+	NextAddressIndex int    `bson:"next_address_index,omitempty" json:"-"`
 }
@@ -1,11 +1,40 @@
 package models
 
+import (
+	"strconv"
+	"time"
+)
+
 type UTXO struct {
-	ID           string  `bson:"_id,omitempty" json:"id"`
-	TxID         string  `bson:"tx_id" json:"tx_id"`
-	Index        int     `bson:"index" json:"index"`
-	OwnerWallet  string  `bson:"owner_wallet" json:"owner_wallet"`
-	Amount       float64 `bson:"amount" json:"amount"`
-	IsSpent      bool    `bson:"is_spent" json:"is_spent"`
-	SpentInTxID  string  `bson:"spent_in_tx_id,omitempty" json:"spent_in_tx_id,omitempty"`
+	ID          string `bson:"_id,omitempty" json:"id"`
+	TxID        string `bson:"tx_id" json:"tx_id"`
+	Index       int    `bson:"index" json:"index"`
+	OwnerWallet string `bson:"owner_wallet" json:"owner_wallet"`
+	Amount      Amount `bson:"amount" json:"amount"`
+	IsSpent     bool   `bson:"is_spent" json:"is_spent"`
+	SpentInTxID string `bson:"spent_in_tx_id,omitempty" json:"spent_in_tx_id,omitempty"`
+
+	// first block height that may spend this output; 0 = no lock
+	MaturityHeight int `bson:"maturity_height,omitempty" json:"maturity_height,omitempty"`
+	// spending condition beyond ownership, e.g. an HTLC
+	Lock *OutputLock `bson:"lock,omitempty" json:"lock,omitempty"`
+
+	// set while a pending tx spends this output; ignored once expired
+	ReservedBy    string     `bson:"reserved_by,omitempty" json:"reserved_by,omitempty"`
+	ReservedUntil *time.Time `bson:"reserved_until,omitempty" json:"reserved_until,omitempty"`
+}
+
+// IsReserved reports whether a pending tx holds u at time now.
+func (u UTXO) IsReserved(now time.Time) bool {
+	return u.ReservedBy != "" && u.ReservedUntil != nil && u.ReservedUntil.After(now)
+}
+
+// IsMature reports whether u may be spent in a block at height.
+func (u UTXO) IsMature(height int) bool {
+	return u.MaturityHeight <= height
+}
+
+// OutpointID is the id of the UTXO created by output index of tx txID.
+func OutpointID(txID string, index int) string {
+	return txID + ":" + strconv.Itoa(index)
 }
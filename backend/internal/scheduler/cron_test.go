@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		expr, from, want string // want "" = never
+	}{
+		{"*/15 * * * *", "2026-10-17 10:07", "2026-10-17 10:15"},
+		{"0 0 1 * *", "2026-01-31 12:00", "2026-02-01 00:00"},
+		{"@monthly", "2026-02-01 00:00", "2026-03-01 00:00"},
+		{"0 0 31 * *", "2026-02-01 00:00", "2026-03-31 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 30 2 *", "2026-03-01 00:00", ""},
+		{"30 23 31 12 *", "2026-12-31 23:30", "2027-12-31 23:30"},
+		{"0 9 * * 1", "2026-10-18 10:00", "2026-10-19 09:00"},
+		{"0 0 * * 7", "2026-10-17 00:00", "2026-10-18 00:00"},
+		{"0 0 * * 5-7", "2026-10-18 00:00", "2026-10-23 00:00"},
+		// both day fields restricted: either one may match
+		{"0 0 13 * 5", "2026-10-17 00:00", "2026-10-23 00:00"},
+		{"0 0 */2 * 0", "2026-10-17 12:00", "2026-10-18 00:00"},
+		// a day field listing every day is unrestricted: both must match
+		{"0 0 1-31 * 1", "2026-10-17 00:00", "2026-10-19 00:00"},
+		{"0 0 15 * 0-6", "2026-10-17 00:00", "2026-11-15 00:00"},
+		{"0 0 15 * 1-7", "2026-10-17 00:00", "2026-11-15 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.expr, err)
+			continue
+		}
+		got := s.Next(at(tt.from))
+		var want time.Time
+		if tt.want != "" {
+			want = at(tt.want)
+		}
+		if !got.Equal(want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.expr, tt.from, got, want)
+		}
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+	} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded", expr)
+		}
+	}
+}
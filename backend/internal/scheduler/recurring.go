@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/hdwallet"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/txbuilder"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ProcessRecurring submits a signed pending transaction for every active
+// recurring transfer due at now. A run that can't be paid, e.g. for lack
+// of balance, is recorded as skipped; either way the schedule moves on to
+// its next run. Runs missed while the server was down are not replayed.
+// Errors on one schedule are logged and the rest still run; only a failed
+// query is returned.
+func ProcessRecurring(ctx context.Context, now time.Time) (submitted, skipped int, err error) {
+	cur, err := db.Col("recurring_transfers").Find(ctx, bson.M{
+		"status":      "active",
+		"next_run_at": bson.M{"$lte": now},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var rt models.RecurringTransfer
+		if err := cur.Decode(&rt); err != nil {
+			continue
+		}
+		ran, run, err := runRecurring(ctx, rt, now)
+		if err != nil {
+			// one failing schedule must not hold up the others
+			log.Printf("recurring %s: %v", rt.ID, err)
+			continue
+		}
+		if !ran {
+			continue
+		}
+		if run.Status == "submitted" {
+			submitted++
+		} else {
+			skipped++
+		}
+	}
+	return submitted, skipped, cur.Err()
+}
+
+// runRecurring claims the due run of rt by advancing its next run time,
+// so concurrent runners can't both pay it, then pays it. ran is false when
+// another runner claimed it first or the schedule has ended.
+func runRecurring(ctx context.Context, rt models.RecurringTransfer, now time.Time) (bool, models.RecurringRun, error) {
+	col := db.Col("recurring_transfers")
+	due := rt.NextRunAt
+
+	if rt.EndAt != nil && due.After(*rt.EndAt) {
+		_, err := col.UpdateOne(ctx,
+			bson.M{"_id": rt.ID, "next_run_at": due},
+			bson.M{"$set": bson.M{"status": "completed", "updated_at": now}},
+		)
+		return false, models.RecurringRun{}, err
+	}
+
+	set := bson.M{"last_run_at": now, "updated_at": now}
+	sched, err := ParseSchedule(rt.Schedule)
+	next := time.Time{}
+	if err == nil {
+		next = sched.Next(now)
+	}
+	if next.IsZero() || (rt.EndAt != nil && next.After(*rt.EndAt)) {
+		set["status"] = "completed"
+	} else {
+		set["next_run_at"] = next
+	}
+
+	res, err := col.UpdateOne(ctx,
+		bson.M{"_id": rt.ID, "status": "active", "next_run_at": due},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, models.RecurringRun{}, err
+	}
+	if res.MatchedCount == 0 {
+		return false, models.RecurringRun{}, nil
+	}
+
+	run := models.RecurringRun{ScheduleID: rt.ID, DueAt: due, RanAt: now, Status: "submitted"}
+	if txID, err := payRecurring(ctx, rt); err != nil {
+		run.Status = "skipped"
+		run.Reason = err.Error()
+	} else {
+		run.TxID = txID
+	}
+
+	counter := "run_count"
+	if run.Status == "skipped" {
+		counter = "skip_count"
+	}
+	if _, err := col.UpdateOne(ctx, bson.M{"_id": rt.ID}, bson.M{"$inc": bson.M{counter: 1}}); err != nil {
+		return true, run, err
+	}
+	_, err = db.Col("recurring_runs").InsertOne(ctx, run)
+	return true, run, err
+}
+
+// payRecurring builds, signs and submits one payment of rt with the
+// owner's custodial key and returns the tx id.
+func payRecurring(ctx context.Context, rt models.RecurringTransfer) (string, error) {
+	var user models.User
+	if err := db.Col("users").FindOne(ctx, bson.M{"wallet_id": rt.OwnerWallet}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", errors.New("owner not found")
+		}
+		return "", err
+	}
+	owned, err := hdwallet.OwnedWallets(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	if err := user.CheckOutgoing(rt.ReceiverWallet, rt.Amount, owned); err != nil {
+		return "", err
+	}
+
+	t := txbuilder.Transfer{
+		Receiver: rt.ReceiverWallet,
+		Amount:   rt.Amount,
+		Note:     rt.Note,
+		AutoFee:  rt.Fee == nil,
+	}
+	if rt.Fee != nil {
+		t.Fee = *rt.Fee
+	}
+
+	tx, _, err := txbuilder.Build(ctx, user, t)
+	if errors.Is(err, utxo.ErrInsufficientFunds) {
+		return "", errors.New("insufficient balance")
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := txbuilder.SignWithCustodialKey(tx, user); err != nil {
+		return "", err
+	}
+	if err := txbuilder.Submit(ctx, tx); err != nil {
+		return "", fmt.Errorf("submit: %w", err)
+	}
+	return tx.ID, nil
+}
+
+// RunRecurring calls ProcessRecurring every interval until ctx is done.
+func RunRecurring(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			submitted, skipped, err := ProcessRecurring(ctx, time.Now().UTC())
+			if err != nil {
+				log.Println("recurring transfers:", err)
+			}
+			if submitted+skipped > 0 {
+				log.Printf("recurring transfers: %d submitted, %d skipped", submitted, skipped)
+			}
+		}
+	}
+}
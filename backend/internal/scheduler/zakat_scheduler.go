@@ -2,15 +2,15 @@ package scheduler
 
 import (
 	"context"
-	"time"
 
-	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
-	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/zakat"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// RunZakatNow queues a signed zakat_deduction transaction for every wallet
+// with a positive balance. They are confirmed when pending txs are mined.
 func RunZakatNow(ctx context.Context) error {
 	walletsCol := db.Col("wallets")
 	cur, err := walletsCol.Find(ctx, bson.M{})
@@ -19,37 +19,13 @@ func RunZakatNow(ctx context.Context) error {
 	}
 	defer cur.Close(ctx)
 
-	pendingCol := db.Col("pending_transactions")
 	for cur.Next(ctx) {
 		var w models.Wallet
 		if err := cur.Decode(&w); err != nil {
 			continue
 		}
-		balance, err := utxo.GetBalance(ctx, w.WalletID)
-		if err != nil || balance <= 0 {
-			continue
-		}
-		zakat := balance * 0.025
-		if zakat <= 0 {
-			continue
-		}
-
-		tx := models.Transaction{
-			SenderWallet:   w.WalletID,
-			ReceiverWallet: config.AppConfig.ZakatWalletID,
-			Amount:         zakat,
-			Note:           "Monthly Zakat",
-			Timestamp:      time.Now().UTC(),
-			Type:           "zakat_deduction",
-			Status:         "pending",
-		}
-		_, _ = pendingCol.InsertOne(ctx, tx)
-
-		// Update user zakat tracking
-		_, _ = db.Col("users").UpdateOne(ctx,
-			bson.M{"wallet_id": w.WalletID},
-			bson.M{"$inc": bson.M{"zakat_deducted": zakat}},
-		)
+		// wallets with nothing due or not enough spendable funds are skipped
+		_, _ = zakat.RunZakatForWallet(ctx, w.WalletID)
 	}
 	return nil
 }
@@ -0,0 +1,319 @@
+package txbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
+	appCrypto "github.com/hafsa-zia/crypto-wallet-backend/internal/crypto"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/mempool"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
+)
+
+// Transfer describes a payment to build from a sender's coins.
+type Transfer struct {
+	Receiver string
+	Amount   models.Amount
+	Note     string
+	Type     string        // defaults to "normal"
+	Fee      models.Amount // paid to the miner on top of Amount
+	AutoFee  bool          // estimate Fee from mempool pressure instead
+	Strategy utxo.Strategy // coin selection, defaults to utxo.DefaultStrategy
+
+	LockTime       int64 // earliest block height or unix time to mine at, see blockchain.IsFinal
+	MaturityHeight int   // first block height that may spend the payment outputs
+
+	// HashLock, when set, locks each payment in an HTLC: the receiver
+	// claims it with the preimage before block Deadline, after which the
+	// sender can refund it.
+	HashLock string
+	Deadline int
+
+	// Multisig is the policy of a multisig sender. The tx is then built
+	// without a single-key signer and left for the members to sign.
+	Multisig *models.MultisigPolicy
+
+	// Payments makes a batch: one output per payment, in order. Receiver
+	// and Amount are then ignored and the tx has no single receiver.
+	Payments []Payment
+}
+
+// Payment is one output of a batch transfer.
+type Payment struct {
+	Receiver string
+	Amount   models.Amount
+}
+
+// Outputs returns the payments t makes, before change.
+func (t Transfer) Outputs() []Payment {
+	if len(t.Payments) > 0 {
+		return t.Payments
+	}
+	return []Payment{{Receiver: t.Receiver, Amount: t.Amount}}
+}
+
+// signatureSize is the largest DER-encoded P-256 signature, used to size
+// a tx before it is signed.
+const signatureSize = 72
+
+// signaturesNeeded is how many signatures t's tx will carry once signed.
+func (t Transfer) signaturesNeeded() int {
+	if t.Multisig != nil {
+		return t.Multisig.Threshold
+	}
+	return 1
+}
+
+// Build selects UTXOs of sender covering t.Amount plus the fee and returns
+// a sealed, unsigned transaction together with the coins it spends.
+// Change, net of the fee, goes back to the sender as the last output;
+// change below utxo.DustLimit is added to the fee instead.
+func Build(ctx context.Context, sender models.User, t Transfer) (*models.Transaction, []models.UTXO, error) {
+	if !t.AutoFee {
+		return build(ctx, sender, t)
+	}
+
+	// the fee depends on the size, which depends on the inputs the fee
+	// pulls in; a few rounds settle it
+	rate := mempool.EstimateFeeRate()
+	t.Fee = 0
+	for {
+		tx, selected, err := build(ctx, sender, t)
+		if err != nil {
+			return nil, nil, err
+		}
+		fee := feeForSize(rate, blockchain.TxSize(*tx)+signatureSize*t.signaturesNeeded())
+		if fee <= tx.Fee {
+			return tx, selected, nil
+		}
+		t.Fee = fee
+	}
+}
+
+// feeForSize is the fee for size bytes at rate per 1000 bytes, rounded up.
+func feeForSize(rate models.Amount, size int) models.Amount {
+	return (rate*models.Amount(size) + 999) / 1000
+}
+
+func build(ctx context.Context, sender models.User, t Transfer) (*models.Transaction, []models.UTXO, error) {
+	if t.Fee < 0 {
+		return nil, nil, fmt.Errorf("%w: fee must not be negative", blockchain.ErrInvalidTx)
+	}
+	if t.Strategy == "" {
+		t.Strategy = utxo.DefaultStrategy
+	}
+	payments := t.Outputs()
+	amount := t.Fee
+	for _, p := range payments {
+		if p.Amount <= 0 {
+			return nil, nil, fmt.Errorf("%w: payment amounts must be positive", blockchain.ErrInvalidTx)
+		}
+		var err error
+		if amount, err = models.AddAmounts(amount, p.Amount, blockchain.MaxMoney()); err != nil {
+			return nil, nil, fmt.Errorf("%w: payments: %w", blockchain.ErrInvalidTx, err)
+		}
+	}
+	amount -= t.Fee
+	receiver := t.Receiver
+	if len(t.Payments) > 0 {
+		receiver = ""
+	}
+
+	selected, change, err := utxo.SelectUTXOsForAmount(ctx, sender.WalletID, amount+t.Fee, t.Strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.Type == "" {
+		t.Type = "normal"
+	}
+	if change < utxo.DustLimit {
+		t.Fee += change
+		change = 0
+	}
+
+	inputs := []models.TxUTXOInput{}
+	for _, u := range selected {
+		inputs = append(inputs, models.TxUTXOInput{UTXOId: u.ID, Index: u.Index})
+	}
+
+	outputs := make([]models.TxUTXOOutput, 0, len(payments)+1)
+	for _, p := range payments {
+		o := models.TxUTXOOutput{
+			OwnerWallet:    p.Receiver,
+			Amount:         p.Amount,
+			MaturityHeight: t.MaturityHeight,
+		}
+		if t.HashLock != "" {
+			o.Lock = &models.OutputLock{
+				Type:      models.LockHTLC,
+				HashLock:  strings.ToLower(t.HashLock),
+				Recipient: p.Receiver,
+				Refund:    sender.WalletID,
+				Deadline:  t.Deadline,
+			}
+		}
+		outputs = append(outputs, o)
+	}
+	if change > 0 {
+		outputs = append(outputs, models.TxUTXOOutput{OwnerWallet: sender.WalletID, Amount: change})
+	}
+
+	tx := &models.Transaction{
+		SenderWallet:   sender.WalletID,
+		ReceiverWallet: receiver,
+		Amount:         amount,
+		Note:           t.Note,
+		Timestamp:      time.Now().UTC(),
+		SenderPubKey:   sender.PublicKey,
+		Inputs:         inputs,
+		Outputs:        outputs,
+		Fee:            t.Fee,
+		Type:           t.Type,
+		LockTime:       t.LockTime,
+		Status:         "pending",
+	}
+	if t.Multisig != nil {
+		tx.SenderPubKey = ""
+		tx.Multisig = &models.MultisigSpend{
+			MultisigPolicy: *t.Multisig,
+			Signatures:     make([]string, len(t.Multisig.PublicKeys)),
+		}
+	}
+	blockchain.SealTx(tx)
+	return tx, selected, nil
+}
+
+// SignWithCustodialKey signs tx with the sender's server-held private key.
+func SignWithCustodialKey(tx *models.Transaction, sender models.User) error {
+	privHex, err := appCrypto.DecryptPrivateKey(sender.EncryptedPrivKey)
+	if err != nil {
+		return fmt.Errorf("decrypt key failed: %w", err)
+	}
+	privKey, pubKey, err := appCrypto.PrivateFromHex(privHex)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	payload := blockchain.SigningPayload(*tx)
+	sig, err := appCrypto.SignMessage(privKey, payload)
+	if err != nil {
+		return fmt.Errorf("sign failed: %w", err)
+	}
+
+	// verify (for safety)
+	if ok, _ := appCrypto.VerifySignature(*pubKey, payload, sig); !ok {
+		return errors.New("signature invalid")
+	}
+	tx.Signature = sig
+	return nil
+}
+
+// BuildHTLCSpend builds an unsigned claim (txType "htlc_claim", revealing
+// preimage) or refund ("htlc_refund") of HTLC output u, paying u back to
+// sender less a fee estimated from mempool pressure.
+func BuildHTLCSpend(ctx context.Context, sender models.User, u models.UTXO, txType, preimage string) (*models.Transaction, error) {
+	if u.Lock == nil {
+		return nil, fmt.Errorf("%w: utxo %s is not an HTLC", blockchain.ErrInvalidTx, u.ID)
+	}
+	tx := &models.Transaction{
+		SenderWallet:   sender.WalletID,
+		ReceiverWallet: sender.WalletID,
+		Note:           txType + " of " + u.ID,
+		Timestamp:      time.Now().UTC(),
+		SenderPubKey:   sender.PublicKey,
+		Inputs:         []models.TxUTXOInput{{UTXOId: u.ID, Index: u.Index}},
+		Type:           txType,
+		Preimage:       preimage,
+		Status:         "pending",
+	}
+
+	// as in Build, the fee and the size settle after a few rounds
+	rate := mempool.EstimateFeeRate()
+	for {
+		tx.Amount = u.Amount - tx.Fee
+		if tx.Amount <= 0 {
+			return nil, fmt.Errorf("%w: HTLC of %s does not cover the fee %s", blockchain.ErrInvalidTx, u.Amount, tx.Fee)
+		}
+		tx.Outputs = []models.TxUTXOOutput{{OwnerWallet: sender.WalletID, Amount: tx.Amount}}
+		blockchain.SealTx(tx)
+		fee := feeForSize(rate, blockchain.TxSize(*tx)+signatureSize)
+		if fee <= tx.Fee {
+			return tx, nil
+		}
+		tx.Fee = fee
+	}
+}
+
+// SignAsMember adds member's signature, made with their server-held key,
+// to a multisig tx.
+func SignAsMember(tx *models.Transaction, member models.User) error {
+	if tx.Multisig == nil {
+		return errors.New("not a multisig transaction")
+	}
+	i := tx.Multisig.IndexOf(strings.ToLower(member.PublicKey))
+	if i < 0 {
+		return errors.New("signer is not a member of this wallet")
+	}
+
+	privHex, err := appCrypto.DecryptPrivateKey(member.EncryptedPrivKey)
+	if err != nil {
+		return fmt.Errorf("decrypt key failed: %w", err)
+	}
+	privKey, _, err := appCrypto.PrivateFromHex(privHex)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+	payload := blockchain.SigningPayload(*tx)
+	sig, err := appCrypto.SignMessage(privKey, payload)
+	if err != nil {
+		return fmt.Errorf("sign failed: %w", err)
+	}
+	if err := blockchain.VerifyMemberSignature(tx.Multisig.PublicKeys[i], payload, sig); err != nil {
+		return err
+	}
+	tx.Multisig.Signatures[i] = sig
+	return nil
+}
+
+// MaxTimestampSkew is how far ahead of the server clock a submitted tx's
+// timestamp may be. Eviction and input reservations count from the
+// timestamp, so a tx dated further ahead could hold its coins for longer
+// than the mempool allows.
+const MaxTimestampSkew = 2 * time.Minute
+
+// Submit validates a signed tx against the next block and adds it to the
+// mempool. A tx that is not final yet waits there until its lock time and
+// inputs' maturity are reached, as long as a time lock falls before the tx
+// would be evicted. Rule violations wrap blockchain.ErrInvalidTx.
+func Submit(ctx context.Context, tx *models.Transaction) error {
+	if tx.Version < blockchain.TxVersionCanonical {
+		return fmt.Errorf("%w: transaction must use the canonical encoding", blockchain.ErrInvalidTx)
+	}
+	if limit := time.Now().UTC().Add(MaxTimestampSkew); tx.Timestamp.After(limit) {
+		return fmt.Errorf("%w: timestamp %s is ahead of the server clock", blockchain.ErrInvalidTx, tx.Timestamp.Format(time.RFC3339))
+	}
+	if tx.LockTime >= blockchain.LockTimeThreshold {
+		if expiry := tx.Timestamp.Add(config.AppConfig.MempoolMaxAge); time.Unix(tx.LockTime, 0).After(expiry) {
+			return fmt.Errorf("%w: lock time is after the tx would leave the mempool at %s", blockchain.ErrInvalidTx, expiry.Format(time.RFC3339))
+		}
+	}
+
+	height, err := blockchain.NextHeight(ctx)
+	if err != nil {
+		return err
+	}
+	// a valid tx's declared fee is exactly its inputs minus outputs
+	_, err = blockchain.ValidateTransaction(ctx, *tx, height, time.Now().UTC(), map[string]bool{})
+	if err != nil && !errors.Is(err, blockchain.ErrNotFinal) {
+		return err
+	}
+
+	tx.Status = "pending"
+	tx.BlockID = ""
+	return mempool.Add(ctx, *tx, tx.Fee)
+}
@@ -3,13 +3,16 @@ package utxo
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func GetBalance(ctx context.Context, walletID string) (float64, error) {
+func GetBalance(ctx context.Context, walletID string) (models.Amount, error) {
 	col := db.Col("utxos")
 	cur, err := col.Find(ctx, bson.M{"owner_wallet": walletID, "is_spent": false})
 	if err != nil {
@@ -18,7 +21,7 @@ func GetBalance(ctx context.Context, walletID string) (float64, error) {
 	defer cur.Close(ctx)
 
 	var u models.UTXO
-	var total float64
+	var total models.Amount
 	for cur.Next(ctx) {
 		if err := cur.Decode(&u); err != nil {
 			return 0, err
@@ -28,27 +31,83 @@ func GetBalance(ctx context.Context, walletID string) (float64, error) {
 	return total, nil
 }
 
-func SelectUTXOsForAmount(ctx context.Context, walletID string, amount float64) ([]models.UTXO, float64, error) {
-	col := db.Col("utxos")
-	cur, err := col.Find(ctx, bson.M{"owner_wallet": walletID, "is_spent": false})
+// FaucetTxPrefix starts the synthetic tx id of admin faucet grants, whose
+// outputs are created outside any block.
+const FaucetTxPrefix = "faucet-"
+
+// TotalUnspent sums every unspent output, faucet grants included.
+func TotalUnspent(ctx context.Context) (models.Amount, int, error) {
+	return sumOutputs(ctx, bson.M{"is_spent": false})
+}
+
+// FaucetIssued sums every faucet grant ever made, spent or not. Spending a
+// grant moves its value into on-chain outputs, so this, not the unspent
+// grants, is what to take off TotalUnspent to get the mined supply.
+func FaucetIssued(ctx context.Context) (models.Amount, error) {
+	total, _, err := sumOutputs(ctx, bson.M{"tx_id": primitive.Regex{Pattern: "^" + FaucetTxPrefix}})
+	return total, err
+}
+
+func sumOutputs(ctx context.Context, match bson.M) (models.Amount, int, error) {
+	cur, err := db.Col("utxos").Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}, "count": bson.M{"$sum": 1}}},
+	})
 	if err != nil {
-		return nil, 0, err
+		return 0, 0, err
 	}
 	defer cur.Close(ctx)
 
-	var chosen []models.UTXO
-	var sum float64
+	var res struct {
+		Total models.Amount `bson:"total"`
+		Count int           `bson:"count"`
+	}
+	if cur.Next(ctx) {
+		if err := cur.Decode(&res); err != nil {
+			return 0, 0, err
+		}
+	}
+	return res.Total, res.Count, cur.Err()
+}
+
+// ErrInsufficientFunds is returned when a wallet's unspent outputs can't
+// cover the requested amount.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// Spendable returns the wallet's unspent outputs that are not held by a
+// pending tx, have matured by the next block and carry no lock such as an
+// HTLC, which only a claim can spend.
+func Spendable(ctx context.Context, walletID string) ([]models.UTXO, error) {
+	height, err := blockchain.NextHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := db.Col("utxos").Find(ctx, bson.M{"owner_wallet": walletID, "is_spent": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	now := time.Now().UTC()
+	var coins []models.UTXO
 	for cur.Next(ctx) {
 		var u models.UTXO
 		if err := cur.Decode(&u); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-		chosen = append(chosen, u)
-		sum += u.Amount
-		if sum >= amount {
-			change := sum - amount
-			return chosen, change, nil
+		if !u.IsReserved(now) && u.IsMature(height) && u.Lock == nil {
+			coins = append(coins, u)
 		}
 	}
-	return nil, 0, errors.New("insufficient funds")
+	return coins, cur.Err()
+}
+
+// SelectUTXOsForAmount picks unspent outputs of walletID covering amount
+// with the given strategy and returns them with the change left over.
+func SelectUTXOsForAmount(ctx context.Context, walletID string, amount models.Amount, strategy Strategy) ([]models.UTXO, models.Amount, error) {
+	coins, err := Spendable(ctx, walletID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return Choose(coins, amount, strategy)
 }
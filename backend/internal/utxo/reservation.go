@@ -0,0 +1,106 @@
+package utxo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/blockchain"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrReserved is returned when an output is already held by another
+// pending transaction.
+var ErrReserved = errors.New("utxo reserved by a pending transaction")
+
+// Reserve marks the given unspent outputs as held by pending tx txID
+// until until. Outputs already held by txID, or whose reservation has
+// expired, can be taken; any other reservation fails with ErrReserved.
+// Run it in the same Mongo transaction that stores the pending tx.
+func Reserve(ctx context.Context, txID string, utxoIDs []string, until time.Time) error {
+	now := time.Now().UTC()
+	for _, id := range utxoIDs {
+		filter := db.ByID(id)
+		filter["is_spent"] = false
+		filter["$or"] = bson.A{
+			bson.M{"reserved_by": bson.M{"$in": bson.A{nil, "", txID}}},
+			bson.M{"reserved_until": bson.M{"$lte": now}},
+		}
+		res, err := db.Col("utxos").UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+			"reserved_by":    txID,
+			"reserved_until": until,
+		}})
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return fmt.Errorf("%w: %s", ErrReserved, id)
+		}
+	}
+	return nil
+}
+
+// Release frees every output held by pending tx txID.
+func Release(ctx context.Context, txID string) error {
+	_, err := db.Col("utxos").UpdateMany(ctx,
+		bson.M{"reserved_by": txID},
+		bson.M{"$unset": bson.M{"reserved_by": "", "reserved_until": ""}},
+	)
+	return err
+}
+
+// Balances splits a wallet's confirmed balance into what pending txs hold,
+// what hasn't matured yet, what sits in HTLCs and what can still be spent.
+type Balances struct {
+	Confirmed models.Amount `json:"confirmed"` // all unspent outputs
+	Reserved  models.Amount `json:"reserved"`  // held by pending txs
+	Immature  models.Amount `json:"immature"`  // not spendable before a later block
+	Locked    models.Amount `json:"locked"`    // HTLCs paying this wallet, spendable only by a claim
+	Spendable models.Amount `json:"spendable"` // confirmed minus all of the above
+}
+
+// GetBalances returns the confirmed, reserved, immature, locked and
+// spendable balance of walletID.
+func GetBalances(ctx context.Context, walletID string) (Balances, error) {
+	height, err := blockchain.NextHeight(ctx)
+	if err != nil {
+		return Balances{}, err
+	}
+	cur, err := db.Col("utxos").Find(ctx, bson.M{"owner_wallet": walletID, "is_spent": false})
+	if err != nil {
+		return Balances{}, err
+	}
+	defer cur.Close(ctx)
+
+	now := time.Now().UTC()
+	var b Balances
+	for cur.Next(ctx) {
+		var u models.UTXO
+		if err := cur.Decode(&u); err != nil {
+			return Balances{}, err
+		}
+		b.Confirmed += u.Amount
+		switch {
+		case u.IsReserved(now):
+			b.Reserved += u.Amount
+		case !u.IsMature(height):
+			b.Immature += u.Amount
+		case u.Lock != nil:
+			b.Locked += u.Amount
+		}
+	}
+	b.Spendable = b.Confirmed - b.Reserved - b.Immature - b.Locked
+	return b, cur.Err()
+}
+
+// Add sums o into b, e.g. to total the addresses of one user.
+func (b *Balances) Add(o Balances) {
+	b.Confirmed += o.Confirmed
+	b.Reserved += o.Reserved
+	b.Immature += o.Immature
+	b.Locked += o.Locked
+	b.Spendable += o.Spendable
+}
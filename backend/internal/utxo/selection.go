@@ -0,0 +1,164 @@
+package utxo
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+)
+
+// Strategy names a coin-selection algorithm.
+type Strategy string
+
+const (
+	LargestFirst   Strategy = "largest-first"
+	SmallestFirst  Strategy = "smallest-first"
+	BranchAndBound Strategy = "branch-and-bound"
+	Random         Strategy = "random"
+)
+
+// DefaultStrategy is used when a request doesn't name one.
+const DefaultStrategy = LargestFirst
+
+// DustLimit is the smallest change worth an output of its own; less than
+// this is left to the miner as fee.
+const DustLimit models.Amount = 1000
+
+// bnbMaxTries bounds the branch-and-bound search on large wallets.
+const bnbMaxTries = 100000
+
+// Selector picks coins summing to at least target. coins may be reordered.
+type Selector func(coins []models.UTXO, target models.Amount) ([]models.UTXO, error)
+
+var selectors = map[Strategy]Selector{
+	LargestFirst:   selectLargestFirst,
+	SmallestFirst:  selectSmallestFirst,
+	BranchAndBound: selectBranchAndBound,
+	Random:         selectRandom,
+}
+
+// Strategies lists every known strategy, default first.
+func Strategies() []Strategy {
+	return []Strategy{LargestFirst, SmallestFirst, BranchAndBound, Random}
+}
+
+// ParseStrategy validates a strategy name; "" selects DefaultStrategy.
+func ParseStrategy(s string) (Strategy, error) {
+	if s == "" {
+		return DefaultStrategy, nil
+	}
+	if _, ok := selectors[Strategy(s)]; !ok {
+		return "", fmt.Errorf("unknown coin selection strategy %q", s)
+	}
+	return Strategy(s), nil
+}
+
+// Choose runs strategy over coins and returns the selection and its change.
+func Choose(coins []models.UTXO, amount models.Amount, strategy Strategy) ([]models.UTXO, models.Amount, error) {
+	sel, ok := selectors[strategy]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown coin selection strategy %q", strategy)
+	}
+	if total(coins) < amount {
+		return nil, 0, ErrInsufficientFunds
+	}
+
+	chosen, err := sel(append([]models.UTXO(nil), coins...), amount)
+	if err != nil {
+		return nil, 0, err
+	}
+	return chosen, total(chosen) - amount, nil
+}
+
+func total(coins []models.UTXO) models.Amount {
+	var sum models.Amount
+	for _, u := range coins {
+		sum += u.Amount
+	}
+	return sum
+}
+
+// accumulate takes coins in order until target is covered.
+func accumulate(coins []models.UTXO, target models.Amount) ([]models.UTXO, error) {
+	var sum models.Amount
+	for i, u := range coins {
+		sum += u.Amount
+		if sum >= target {
+			return coins[:i+1], nil
+		}
+	}
+	return nil, ErrInsufficientFunds
+}
+
+// selectLargestFirst spends the fewest coins.
+func selectLargestFirst(coins []models.UTXO, target models.Amount) ([]models.UTXO, error) {
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].Amount > coins[j].Amount })
+	return accumulate(coins, target)
+}
+
+// selectSmallestFirst consolidates small coins.
+func selectSmallestFirst(coins []models.UTXO, target models.Amount) ([]models.UTXO, error) {
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].Amount < coins[j].Amount })
+	return accumulate(coins, target)
+}
+
+// selectRandom takes coins in random order so spends reveal less about
+// which coins belong together.
+func selectRandom(coins []models.UTXO, target models.Amount) ([]models.UTXO, error) {
+	rand.Shuffle(len(coins), func(i, j int) { coins[i], coins[j] = coins[j], coins[i] })
+	return accumulate(coins, target)
+}
+
+// selectBranchAndBound searches for a set of coins summing to target
+// within DustLimit, so the tx needs no change output. It prefers the
+// smallest overshoot found and falls back to largest-first when no such
+// set exists.
+func selectBranchAndBound(coins []models.UTXO, target models.Amount) ([]models.UTXO, error) {
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].Amount > coins[j].Amount })
+
+	// remaining[i] is the value of coins[i:], for pruning branches that
+	// can no longer reach target
+	remaining := make([]models.Amount, len(coins)+1)
+	for i := len(coins) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + coins[i].Amount
+	}
+
+	var best []int
+	bestWaste := DustLimit
+	picked := []int{}
+	tries := 0
+
+	var search func(i int, sum models.Amount)
+	search = func(i int, sum models.Amount) {
+		tries++
+		if tries > bnbMaxTries || bestWaste == 0 {
+			return
+		}
+		if sum >= target {
+			if waste := sum - target; waste < bestWaste {
+				bestWaste = waste
+				best = append(best[:0], picked...)
+			}
+			return
+		}
+		if i == len(coins) || sum+remaining[i] < target {
+			return
+		}
+
+		picked = append(picked, i)
+		search(i+1, sum+coins[i].Amount)
+		picked = picked[:len(picked)-1]
+		search(i+1, sum)
+	}
+	search(0, 0)
+
+	if best == nil {
+		return selectLargestFirst(coins, target)
+	}
+	chosen := make([]models.UTXO, 0, len(best))
+	for _, i := range best {
+		chosen = append(chosen, coins[i])
+	}
+	return chosen, nil
+}
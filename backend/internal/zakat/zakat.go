@@ -4,128 +4,71 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/config"
 	"github.com/hafsa-zia/crypto-wallet-backend/internal/db"
-	"github.com/hafsa-zia/crypto-wallet-backend/pkg/logger"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/models"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/txbuilder"
+	"github.com/hafsa-zia/crypto-wallet-backend/internal/utxo"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ZakatWalletID receives zakat when ZAKAT_WALLET_ID is not configured.
 const ZakatWalletID = "ZAKAT_POOL"
 
-func RunZakatForWallet(c *gin.Context, walletID string) (bson.M, error) {
-	ctx := context.Background()
+// ErrNotDue is returned when a wallet owes no zakat.
+var ErrNotDue = errors.New("zakat not due")
 
-	utxosCol := db.Col("utxos")
-	txsCol := db.Col("transactions")
-	usersCol := db.Col("users")
+// Due is the zakat owed on balance: 2.5%, rounded down to a base unit.
+func Due(balance models.Amount) models.Amount {
+	return balance * 25 / 1000
+}
 
-	// TODO: adjust "owner_wallet" + "spent" to match YOUR utxo schema
-	cur, err := utxosCol.Find(ctx, bson.M{
-		"owner_wallet": walletID,
-		"spent":        false,
-	})
-	if err != nil {
-		return nil, err
+// PoolWallet is the wallet zakat is paid to.
+func PoolWallet() string {
+	if config.AppConfig.ZakatWalletID != "" {
+		return config.AppConfig.ZakatWalletID
 	}
-	defer cur.Close(ctx)
+	return ZakatWalletID
+}
 
-	var utxos []bson.M
-	if err := cur.All(ctx, &utxos); err != nil {
+// RunZakatForWallet submits a signed zakat_deduction tx paying 2.5% of
+// walletID's confirmed balance to the pool. Like any other spend it is
+// built from real inputs, signed with the wallet's key and validated by
+// the mempool, then confirmed by mining.
+func RunZakatForWallet(ctx context.Context, walletID string) (*models.Transaction, error) {
+	balance, err := utxo.GetBalance(ctx, walletID)
+	if err != nil {
 		return nil, err
 	}
-	if len(utxos) == 0 {
-		return nil, errors.New("zakat not due")
-	}
-
-	var balance float64
-	for _, u := range utxos {
-		if amt, ok := u["amount"].(float64); ok {
-			balance += amt
-		}
-	}
-	if balance <= 0 {
-		return nil, errors.New("balance <= 0, zakat not due")
-	}
-
-	zakatAmount := balance * 0.025
-	if zakatAmount <= 0 {
-		return nil, errors.New("zakat computed as 0, nothing to do")
-	}
-
-	var inputs []bson.M
-	var inputSum float64
-
-	for _, u := range utxos {
-		rawID, hasID := u["_id"]
-		if !hasID {
-			continue
-		}
-		oid, ok := rawID.(primitive.ObjectID)
-		if !ok {
-			continue
-		}
-		amt, _ := u["amount"].(float64)
-
-		inputs = append(inputs, bson.M{
-			"utxo_id": oid.Hex(),
-		})
-		inputSum += amt
-
-		if inputSum >= zakatAmount {
-			break
-		}
+	due := Due(balance)
+	if due <= 0 {
+		return nil, fmt.Errorf("%w: balance %s", ErrNotDue, balance)
 	}
 
-	if inputSum < zakatAmount {
-		return nil, errors.New("not enough UTXOs to cover zakat")
+	var user models.User
+	if err := db.Col("users").FindOne(ctx, bson.M{"wallet_id": walletID}).Decode(&user); err != nil {
+		return nil, err
 	}
-
-	change := inputSum - zakatAmount
-
-	var outputs []bson.M
-	outputs = append(outputs, bson.M{
-		"owner_wallet": ZakatWalletID,
-		"amount":       zakatAmount,
+	tx, _, err := txbuilder.Build(ctx, user, txbuilder.Transfer{
+		Receiver: PoolWallet(),
+		Amount:   due,
+		Note:     "Monthly Zakat",
+		Type:     "zakat_deduction",
 	})
-	if change > 0 {
-		outputs = append(outputs, bson.M{
-			"owner_wallet": walletID,
-			"amount":       change,
-		})
+	if err != nil {
+		return nil, err
 	}
-
-	now := time.Now().UTC()
-
-	txDoc := bson.M{
-		"sender_wallet":   walletID,
-		"receiver_wallet": ZakatWalletID,
-		"amount":          zakatAmount,
-		"note":            "Monthly zakat deduction (2.5%)",
-		"type":            "zakat_deduction",
-		"status":          "pending",
-		"timestamp":       now,
-		"inputs":          inputs,
-		"outputs":         outputs,
+	if err := txbuilder.SignWithCustodialKey(tx, user); err != nil {
+		return nil, err
 	}
-
-	res, err := txsCol.InsertOne(ctx, txDoc)
-	if err != nil {
+	if err := txbuilder.Submit(ctx, tx); err != nil {
 		return nil, err
 	}
 
-	txDoc["_id"] = res.InsertedID
-
-	_, _ = usersCol.UpdateOne(ctx, bson.M{"wallet_id": walletID}, bson.M{
-		"$inc": bson.M{"zakat_deducted": zakatAmount},
-	})
-
-	logger.AddSystemLog(c,
-		"zakat_created",
-		fmt.Sprintf("wallet=%s amount=%.4f", walletID, zakatAmount),
+	_, err = db.Col("users").UpdateOne(ctx,
+		bson.M{"wallet_id": walletID},
+		bson.M{"$inc": bson.M{"zakat_deducted": due}},
 	)
-
-	return txDoc, nil
+	return tx, err
 }
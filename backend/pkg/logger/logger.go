@@ -21,3 +21,17 @@ func AddSystemLog(c *gin.Context, event, details string) {
 
 	_, _ = col.InsertOne(context.Background(), log)
 }
+
+// AddSystemEvent records a log entry raised outside of an HTTP request,
+// e.g. by a background job, so there is no client IP.
+func AddSystemEvent(event, details string) {
+	col := db.Col("system_logs")
+
+	log := models.SystemLog{
+		Event:     event,
+		Details:   details,
+		Timestamp: time.Now().UTC(),
+	}
+
+	_, _ = col.InsertOne(context.Background(), log)
+}
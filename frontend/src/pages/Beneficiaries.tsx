@@ -2,19 +2,28 @@ import type { FormEvent } from "react";
 import { useEffect, useState } from "react";
 import api from "../api/client";
 
+type Beneficiary = {
+  name: string;
+  wallet_id: string;
+  label?: string;
+  default_amount?: string;
+};
+
 export default function Beneficiaries() {
-  const [items, setItems] = useState<string[]>([]);
+  const [items, setItems] = useState<Beneficiary[]>([]);
   const [newItem, setNewItem] = useState("");
+  const [newName, setNewName] = useState("");
   const [msg, setMsg] = useState("");
 
   useEffect(() => {
-    api.get("/wallet").then((res: { data: { beneficiaries?: string[] } }) => setItems(res.data.beneficiaries || []));
+    api.get("/wallet/beneficiaries").then((res: { data: { beneficiaries?: Beneficiary[] } }) => setItems(res.data.beneficiaries || []));
   }, []);
 
   const add = () => {
     if (!newItem) return;
-    setItems((prev) => [...prev, newItem]);
+    setItems((prev) => [...prev, { name: newName || newItem, wallet_id: newItem }]);
     setNewItem("");
+    setNewName("");
   };
 
   const onSubmit = async (e: FormEvent) => {
@@ -36,6 +45,12 @@ export default function Beneficiaries() {
       {msg && <div className="text-sm text-blue-600">{msg}</div>}
       <form onSubmit={onSubmit} className="space-y-3 bg-white p-4 rounded-xl shadow">
         <div className="flex gap-2">
+          <input
+            className="w-32 border rounded-lg px-3 py-2"
+            placeholder="Nickname"
+            value={newName}
+            onChange={(e) => setNewName(e.target.value)}
+          />
           <input
             className="flex-1 border rounded-lg px-3 py-2"
             placeholder="Wallet ID"
@@ -53,7 +68,10 @@ export default function Beneficiaries() {
         <ul className="space-y-1 text-sm">
           {items.map((b, i) => (
             <li key={i} className="flex justify-between">
-              <span className="font-mono">{b}</span>
+              <span>
+                {b.name}{" "}
+                <span className="font-mono text-slate-500">{b.wallet_id}</span>
+              </span>
               <button
                 type="button"
                 onClick={() =>
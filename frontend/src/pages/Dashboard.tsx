@@ -5,7 +5,7 @@ import { useAuth } from "../context/AuthContext";
 type UTXO = {
   id?: string;
   _id?: string;
-  amount: number;
+  amount: string; // decimal string, 8 places
 };
 
 export default function Dashboard() {
@@ -78,7 +78,7 @@ export default function Dashboard() {
         api.get("/tx/history"),
       ]);
 
-      setBalance(balanceRes.data.balance ?? 0);
+      setBalance(Number(balanceRes.data.balance ?? 0));
 
       const u = utxoRes.data.utxos ?? [];
       setUtxos(Array.isArray(u) ? u : []);
@@ -3,11 +3,11 @@ import api from "../api/client";
 import { useAuth } from "../context/AuthContext";
 
 type ReportSummary = {
-  total_sent_amount: number;
+  total_sent_amount: string;
   total_sent_count: number;
-  total_received_amount: number;
+  total_received_amount: string;
   total_received_count: number;
-  zakat_deducted_amount: number;
+  zakat_deducted_amount: string;
   zakat_deducted_tx_count: number;
 };
 
@@ -69,8 +69,8 @@ export default function Reports() {
 };
 
 
-  const formatAmount = (val?: number) =>
-    typeof val === "number" ? val.toFixed(4) : "0.0000";
+  const formatAmount = (val?: string) =>
+    val ? Number(val).toFixed(4) : "0.0000";
 
   return (
     <div className="main-column">
@@ -179,9 +179,9 @@ export default function Reports() {
                 ? "..."
                 : (() => {
                     const net =
-                      (summary?.total_received_amount || 0) -
-                      (summary?.total_sent_amount || 0) -
-                      (summary?.zakat_deducted_amount || 0);
+                      Number(summary?.total_received_amount || 0) -
+                      Number(summary?.total_sent_amount || 0) -
+                      Number(summary?.zakat_deducted_amount || 0);
                     const prefix = net >= 0 ? "+" : "";
                     return `${prefix}${net.toFixed(4)} CWD`;
                   })()}
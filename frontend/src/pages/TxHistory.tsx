@@ -6,7 +6,7 @@ type Tx = {
   _id?: string;
   sender_wallet?: string;
   receiver_wallet?: string;
-  amount?: number;
+  amount?: string;
   note?: string;
   timestamp?: string;
   type?: string;
@@ -6,9 +6,9 @@ type Profile = {
   email: string;
   cnic: string;
   wallet_id: string;
-  beneficiaries: string[];
-  zakat_deducted: number;
-  balance: number;
+  beneficiaries: { name: string; wallet_id: string }[];
+  zakat_deducted: string;
+  balance: string;
 };
 
 export default function WalletProfile() {
@@ -37,8 +37,8 @@ export default function WalletProfile() {
         <h2 className="text-lg font-semibold mb-2">Beneficiaries</h2>
         <ul className="list-disc list-inside text-sm">
           {data.beneficiaries.map((b) => (
-            <li key={b} className="font-mono">
-              {b}
+            <li key={b.name}>
+              {b.name} <span className="font-mono">{b.wallet_id}</span>
             </li>
           ))}
         </ul>